@@ -2,7 +2,7 @@ package examples
 
 import (
 	bdx "github.com/belldata-dx/bdx"
-	logger ""
+	logger "github.com/belldata-dx/bdx-logger"
 	"github.com/belldata-dx/bdx/di"
 	"github.com/belldata-dx/bdx/infra"
 	"github.com/belldata-dx/bdx/interfaces"
@@ -45,7 +45,7 @@ type (
 
 	// studentHandler application層の実装を持つ構造体
 	studentHandler struct {
-		u IStudentUseCase
+		U IStudentUseCase `inject:""`
 	}
 )
 
@@ -57,8 +57,7 @@ var (
 func NewStudentInfra(db *infra.DB) StudentRepo {
 	return &studentInfra{
 		RepositoryImple: infra.RepositoryImple{
-			Master: db.Master,
-			Slave:  db.Slave,
+			DB: db,
 		},
 	}
 }
@@ -93,27 +92,26 @@ func (u *studentUseCaseImpl) Find() (result []*StudentModel) {
 	return u.repo.Find()
 }
 
-// NewStudentHandler application層の実装を持つインスタンス
-func NewStudentHandler(u IStudentUseCase) StudentHandle {
-	return &studentHandler{u}
-}
-
 // Post applicatoin層の実装
 func (u *studentHandler) Post(c interfaces.Context) {
-	s, _ := u.u.Create(StudentModel{Name: "test"})
+	s, _ := u.U.Create(StudentModel{Name: "test"})
 	c.JSON(200, s)
 }
 
 // Get application層の実装
 func (u *studentHandler) Get(c interfaces.Context) {
-	s := u.u.Find()
+	s := u.U.Find()
 	c.JSON(200, s)
 }
 
+// Count application層の実装(引数でUseCase層を受け取る)
+func Count(c interfaces.Context, u IStudentUseCase) {
+	c.JSON(200, bdx.B{"count": len(u.Find())})
+}
+
 var (
-	studentInf    interface{}
-	studentUse    interface{}
-	studentHandle interface{}
+	studentInf interface{}
+	studentUse interface{}
 )
 
 func main() {
@@ -135,15 +133,11 @@ func main() {
 		Builder: NewStudentUseCase,
 	})
 
-	// コンテナへセット
-	studentHandle = container.Set(&di.Definition{
-		// DI
-		DiName:  []interface{}{studentUse},
-		Builder: NewStudentHandler,
-	})
-
-	// コンテナから取得(この時に依存関係が全て解決される。)
-	sHandler := container.Get(studentHandle).(StudentHandle)
+	// `inject`タグが設定されたフィールドへ注入(この時に依存関係が全て解決される。)
+	sHandler := &studentHandler{}
+	if err := container.Populate(sHandler); err != nil {
+		panic(err)
+	}
 
 	// ルーターのインスタンス生成
 	router := bdx.Default()
@@ -152,6 +146,8 @@ func main() {
 
 	router.POST("/student", sHandler.Post)
 	router.GET("/student", sHandler.Get)
+	// 2番目以降の引数はリクエストの度にコンテナから解決される
+	router.GET("/student/count", container.Handler(Count))
 
 	router.Run()
 }
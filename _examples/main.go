@@ -1,14 +1,34 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/belldata-dx/bdx"
+	"github.com/belldata-dx/bdx/health"
 	"github.com/belldata-dx/bdx/interfaces"
 )
 
 func main() {
 	r := bdx.New()
-	r.GET("/health", func(c interfaces.Context) {
+	h := health.New()
+	r.GET("/livez", h.LivezHandler())
+	r.GET("/readyz", h.ReadyzHandler())
+	r.OnShutdown(h.Shutdown)
+	r.GET("/hello", func(c interfaces.Context) {
 		c.JSON(200, bdx.B{"data": "test"})
 	})
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+		<-sig
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		r.Shutdown(ctx)
+	}()
 	r.Run()
 }
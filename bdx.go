@@ -3,36 +3,55 @@ package bdx
 import (
 	contextPac "context"
 	"net/http"
+	"sync"
+	"time"
 
 	logger "github.com/belldata-dx/bdx-logger"
 	"github.com/belldata-dx/bdx/bdxctx"
+	"github.com/belldata-dx/bdx/cookie"
 	"github.com/belldata-dx/bdx/interfaces"
 	"github.com/belldata-dx/bdx/middleware"
 	"github.com/belldata-dx/bdx/param"
+	"github.com/belldata-dx/bdx/render"
+	"github.com/belldata-dx/bdx/websocket"
 	"github.com/julienschmidt/httprouter"
 )
 
 const (
 	defaultMaxMultipartMemory = 32 << 20 // 32MB
+	defaultSSEHeartbeat       = 15 * time.Second
 )
 
 type (
-	contextKey struct{}
-
 	// Engine bdxフレームワークインスタンス
 	Engine struct {
 		RouterGroup
 		maxMultipartMemory int64
+		maxUploadSize      int64
 		maxParams          uint16
+		constraintStatus   int
+		cookie             cookie.Config
+		html               *render.HTMLTemplates
+		negotiateDefault   string
+		sseHeartbeat       time.Duration
+		jsonCodec          render.JSONCodec
+		wsOptions          websocket.Options
 		log                logger.ILogger
+		onStart            []func() error
+		onShutdown         []func()
+		shutdownDelay      time.Duration
+		mu                 sync.Mutex
+		server             *http.Server
+		closed             bool
+		wsConns            map[*websocket.Conn]struct{}
 	}
 )
 
 // DefaultLogger デフォルトで使用されるlogger
 var DefaultLogger logger.ILogger
 
-// ContextKey `request.Context()`で取得できるコンテキストキー
-var ContextKey = contextKey{}
+// ContextKey `request.Context()`で取得できるコンテキストキー(`interfaces.ContextKey`)
+var ContextKey = interfaces.ContextKey
 
 var _ interfaces.Engine = &Engine{}
 
@@ -47,6 +66,10 @@ func New() (engine *Engine) {
 		},
 		log:                DefaultLogger,
 		maxMultipartMemory: defaultMaxMultipartMemory,
+		constraintStatus:   http.StatusNotFound,
+		cookie:             cookie.DefaultConfig(),
+		sseHeartbeat:       defaultSSEHeartbeat,
+		jsonCodec:          render.StdJSONCodec{},
 	}
 	engine.engine = engine
 	engine.pool.New = func() interface{} {
@@ -90,18 +113,124 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	c.SetHandler(handlers)
 	c.Next()
+	// net/httpは元のリクエストの一時ファイルしか削除しないため、WithContextで複製したリクエストの分を削除する
+	c.RemoveMultipartForm()
 	engine.pool.Put(c)
 }
 
+// OnStart `Run`、`RunTLS`でサーバを起動する前に実行する処理を追加します。
+// 登録順に実行し、エラーが返された場合はサーバを起動せずにそのエラーを返します。
+func (engine *Engine) OnStart(fn ...func() error) {
+	engine.onStart = append(engine.onStart, fn...)
+}
+
+func (engine *Engine) start() error {
+	for _, fn := range engine.onStart {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnShutdown `Shutdown`でサーバを停止する前に実行する処理を追加します。
+// 登録順に実行します。
+func (engine *Engine) OnShutdown(fn ...func()) {
+	engine.onShutdown = append(engine.onShutdown, fn...)
+}
+
+// SetShutdownDelay `Shutdown`で`OnShutdown`の処理を実行してから新しい接続の受付を停止するまでの待機時間を設定します。(デフォルト0)
+// `OnShutdown`でreadiness probeを失敗させ、ロードバランサが振り分けを止めるまでの間もリクエストを処理できるようにします。
+//     router.OnShutdown(func() { ready.Store(false) })
+//     router.SetShutdownDelay(5 * time.Second)
+func (engine *Engine) SetShutdownDelay(d time.Duration) {
+	engine.shutdownDelay = d
+}
+
+// Shutdown `OnShutdown`で登録した処理を実行し、`SetShutdownDelay`の時間だけ待機した後、
+// 新しい接続の受付を停止し、処理中のリクエストの完了を待ってサーバを停止します。
+// `WS`でアップグレードした接続は1001 Going Awayで閉じます。
+// `ctx`が完了した場合は待機を中断してそのエラーを返します。
+//     go router.Run()
+//     <-sig
+//     router.Shutdown(ctx)
+func (engine *Engine) Shutdown(ctx contextPac.Context) error {
+	for _, fn := range engine.onShutdown {
+		fn()
+	}
+	if engine.shutdownDelay > 0 {
+		timer := time.NewTimer(engine.shutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	engine.mu.Lock()
+	server := engine.server
+	engine.closed = true
+	engine.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+func (engine *Engine) serve(server *http.Server, listen func(*http.Server) error) error {
+	if err := engine.start(); err != nil {
+		return err
+	}
+	engine.mu.Lock()
+	if engine.closed {
+		engine.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	engine.server = server
+	engine.mu.Unlock()
+	// http.Server.Shutdownはハイジャックした接続を閉じないため、WebSocketの接続はここで閉じる
+	server.RegisterOnShutdown(engine.closeWebSockets)
+	return listen(server)
+}
+
+// trackWebSocket `Shutdown`で閉じるWebSocketの接続を登録、解除します。
+func (engine *Engine) trackWebSocket(conn *websocket.Conn, add bool) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	if !add {
+		delete(engine.wsConns, conn)
+		return
+	}
+	if engine.wsConns == nil {
+		engine.wsConns = map[*websocket.Conn]struct{}{}
+	}
+	engine.wsConns[conn] = struct{}{}
+}
+
+func (engine *Engine) closeWebSockets() {
+	engine.mu.Lock()
+	conns := make([]*websocket.Conn, 0, len(engine.wsConns))
+	for conn := range engine.wsConns {
+		conns = append(conns, conn)
+	}
+	engine.mu.Unlock()
+	for _, conn := range conns {
+		conn.Close(websocket.CloseGoingAway, "")
+	}
+}
+
 // Run ListenAndServe
 func (engine *Engine) Run(addr ...string) error {
 	address := resolveAddress(addr)
-	return http.ListenAndServe(address, engine)
+	return engine.serve(&http.Server{Addr: address, Handler: engine}, func(s *http.Server) error {
+		return s.ListenAndServe()
+	})
 }
 
 // RunTLS ListenAndServeTLS
 func (engine *Engine) RunTLS(addr string, certFile string, keyFile string) error {
-	return http.ListenAndServeTLS(addr, certFile, keyFile, engine)
+	return engine.serve(&http.Server{Addr: addr, Handler: engine}, func(s *http.Server) error {
+		return s.ListenAndServeTLS(certFile, keyFile)
+	})
 }
 
 // SetLogger Logger Change
@@ -115,6 +244,114 @@ func (engine *Engine) SetLogLevel(level logger.LogLevel) {
 	engine.log.SetLevel(level)
 }
 
+// SetConstraintStatus パスパラメータの制約を満たさないリクエストへ返すステータスを設定します。(デフォルト404)
+//     router.SetConstraintStatus(http.StatusBadRequest)
+func (engine *Engine) SetConstraintStatus(code int) {
+	engine.constraintStatus = code
+}
+
+// SetCookieConfig `Context.SetCookie`などで使用するCookieのデフォルト属性と署名・暗号化の鍵を設定します。
+//     cfg := cookie.DefaultConfig()
+//     cfg.Secure = true
+//     cfg.Keys = [][]byte{newKey, oldKey}
+//     router.SetCookieConfig(cfg)
+func (engine *Engine) SetCookieConfig(cfg cookie.Config) {
+	engine.cookie = cfg
+}
+
+// CookieConfig Cookieのデフォルト属性と署名・暗号化の鍵
+func (engine *Engine) CookieConfig() cookie.Config {
+	return engine.cookie
+}
+
+// SetHTMLTemplates `Context.HTML`で使用するテンプレートを登録し、読み込みます。
+//     t := render.FSTemplates(http.Dir("templates"), "/")
+//     t.FuncMap = template.FuncMap{"upper": strings.ToUpper}
+//     t.Layout = "layouts/base.html"
+//     t.Debug = true
+//     router.SetHTMLTemplates(t)
+func (engine *Engine) SetHTMLTemplates(t *render.HTMLTemplates) error {
+	if err := t.Load(); err != nil {
+		return err
+	}
+	engine.html = t
+	return nil
+}
+
+// LoadHTMLGlob `pattern`に一致するテンプレートを登録します。
+// `render.GlobTemplates(pattern)`を`SetHTMLTemplates`するのと同じです。
+func (engine *Engine) LoadHTMLGlob(pattern string) error {
+	return engine.SetHTMLTemplates(render.GlobTemplates(pattern))
+}
+
+// HTMLTemplates 登録されているテンプレート
+func (engine *Engine) HTMLTemplates() *render.HTMLTemplates {
+	return engine.html
+}
+
+// SetNegotiateDefault `Context.Negotiate`で`Accept`ヘッダーに一致する形式が無い場合に返す形式を設定します。
+// 設定しない場合は406 Not Acceptableを返します。
+//     router.SetNegotiateDefault(middleware.MIMEJSON)
+func (engine *Engine) SetNegotiateDefault(format string) {
+	engine.negotiateDefault = format
+}
+
+// NegotiateDefault `Accept`ヘッダーに一致する形式が無い場合に返す形式
+func (engine *Engine) NegotiateDefault() string {
+	return engine.negotiateDefault
+}
+
+// SetSSEHeartbeat `Context.Stream`でハートビートを送信する間隔を設定します。(デフォルト15秒)
+// 0以下の場合はハートビートを送信しません。
+func (engine *Engine) SetSSEHeartbeat(d time.Duration) {
+	engine.sseHeartbeat = d
+}
+
+// SSEHeartbeat `Context.Stream`でハートビートを送信する間隔
+func (engine *Engine) SSEHeartbeat() time.Duration {
+	return engine.sseHeartbeat
+}
+
+// SetJSONCodec `JSON`などのレンダラとリクエストボディのデコードで使用するJSONの実装を設定します。
+// リクエストを処理する前に設定してください。`nil`の場合は`encoding/json`に戻します。
+//     router.SetJSONCodec(codec{})
+func (engine *Engine) SetJSONCodec(codec render.JSONCodec) {
+	if codec == nil {
+		codec = render.StdJSONCodec{}
+	}
+	engine.jsonCodec = codec
+}
+
+// JSONCodec `JSON`などのレンダラとリクエストボディのデコードで使用するJSONの実装
+func (engine *Engine) JSONCodec() render.JSONCodec {
+	return engine.jsonCodec
+}
+
+// SetWebSocketOptions `WS`で登録したハンドラのアップグレードと接続の設定をします。
+// 設定しない場合は同じオリジンのみ許可し、Pingフレームは送信しません。
+func (engine *Engine) SetWebSocketOptions(opts websocket.Options) {
+	engine.wsOptions = opts
+}
+
+// SetMaxMultipartMemory マルチパートフォームの解析でメモリに保持する最大サイズを設定します。(デフォルト32MB)
+// 超えた部分は一時ファイルに保存します。
+//     router.SetMaxMultipartMemory(8 << 20) // 8MB
+func (engine *Engine) SetMaxMultipartMemory(size int64) {
+	engine.maxMultipartMemory = size
+}
+
+// SetMaxUploadSize マルチパートフォームのリクエストボディの最大サイズを設定します。(デフォルトは制限なし)
+// 超えた場合は`Context.FormFile`などがエラーを返し、`Context.PostForm`などは値を返しません。0以下の場合は制限しません。
+//     router.SetMaxUploadSize(100 << 20) // 100MB
+func (engine *Engine) SetMaxUploadSize(size int64) {
+	engine.maxUploadSize = size
+}
+
+// MaxUploadSize マルチパートフォームのリクエストボディの最大サイズ
+func (engine *Engine) MaxUploadSize() int64 {
+	return engine.maxUploadSize
+}
+
 // MaxMultipartMemory Multipartコンテンツタイプで許容される量
 func (engine *Engine) MaxMultipartMemory() int64 {
 	return engine.maxMultipartMemory
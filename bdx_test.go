@@ -1,21 +1,38 @@
 package bdx_test
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/belldata-dx/bdx"
 	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/cookie"
 	"github.com/belldata-dx/bdx/interfaces"
 	"github.com/belldata-dx/bdx/middleware"
 	"github.com/belldata-dx/bdx/param"
+	"github.com/belldata-dx/bdx/render"
+	"github.com/belldata-dx/bdx/websocket"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 var (
@@ -119,3 +136,665 @@ func TestPathParameter(t *testing.T) {
 	request(router, http.MethodGet, "/123", "")
 	assert.Equal(t, id, "123")
 }
+
+func TestRenderContentType(t *testing.T) {
+	router := bdx.New()
+	router.GET("/json", func(c interfaces.Context) {
+		c.JSON(http.StatusCreated, bdx.B{"id": 1})
+	})
+	router.GET("/xml", func(c interfaces.Context) {
+		c.XML(http.StatusOK, "1")
+	})
+	router.GET("/yaml", func(c interfaces.Context) {
+		c.YAML(http.StatusOK, bdx.B{"id": 1})
+	})
+	router.GET("/no-content", func(c interfaces.Context) {
+		c.JSON(http.StatusNoContent, nil)
+	})
+
+	// `w.Header()`はWriteHeaderの後に設定したヘッダーも返すため、送信されたヘッダーで確認する
+	for path, contentType := range map[string]string{
+		"/json":       "application/json; charset=utf-8",
+		"/xml":        "application/xml; charset=utf-8",
+		"/yaml":       "application/x-yaml; charset=utf-8",
+		"/no-content": "application/json; charset=utf-8",
+	} {
+		w := request(router, http.MethodGet, path, "")
+		assert.Equal(t, contentType, w.Result().Header.Get("Content-Type"), path)
+	}
+	assert.Equal(t, http.StatusCreated, request(router, http.MethodGet, "/json", "").Code)
+}
+
+func TestRouteConstraint(t *testing.T) {
+	var id int
+	router := bdx.New()
+	router.SetLogger(newlog())
+	status := 0
+	router.Use(func(c interfaces.Context) {
+		c.Next()
+		status = c.ResponseStatus()
+	})
+	middlewares := []string{}
+	groupStatus := 0
+	group := router.Group("/students", func(c interfaces.Context) {
+		middlewares = append(middlewares, "group")
+		c.Next()
+		groupStatus = c.ResponseStatus()
+	})
+	called := false
+	group.GET("/:id<int>", func(c interfaces.Context) {
+		middlewares = append(middlewares, "route")
+	}, func(c interfaces.Context) {
+		called = true
+		id, _ = c.ParamInt("id")
+		c.JSON(http.StatusOK, bdx.B{"id": id})
+	})
+	w := request(router, http.MethodGet, "/students/123", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 123, id)
+	assert.True(t, called)
+
+	// ミドルウェアは全て実行され、ハンドラは実行されない
+	called = false
+	middlewares = middlewares[:0]
+	w = request(router, http.MethodGet, "/students/abc", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusNotFound, groupStatus)
+	assert.Equal(t, []string{"group", "route"}, middlewares)
+	assert.False(t, called)
+
+	router.GET("/teachers/:id<int>", func(c interfaces.Context) {
+		called = true
+	})
+	w = request(router, http.MethodGet, "/teachers/abc", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.False(t, called)
+
+	router.SetConstraintStatus(http.StatusBadRequest)
+	w = request(router, http.MethodGet, "/students/abc", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Panics(t, func() {
+		router.GET("/courses/:id<unknown>", func(c interfaces.Context) {})
+	})
+}
+
+func TestCookie(t *testing.T) {
+	router := bdx.New()
+	cfg := cookie.DefaultConfig()
+	cfg.Keys = [][]byte{[]byte("secret")}
+	cfg.SameSite = http.SameSiteStrictMode
+	router.SetCookieConfig(cfg)
+	router.GET("/set", func(c interfaces.Context) {
+		c.SetCookie("lang", "日本語", 0)
+		assert.Nil(t, c.SetSignedCookie("user", "taro", 3600))
+		assert.Nil(t, c.SetEncryptedCookie("token", "abc", 3600))
+		c.Status(http.StatusNoContent)
+	})
+	values := map[string]string{}
+	router.GET("/get", func(c interfaces.Context) {
+		var err error
+		values["lang"], err = c.Cookie("lang")
+		assert.Nil(t, err)
+		values["user"], err = c.SignedCookie("user")
+		assert.Nil(t, err)
+		values["token"], err = c.EncryptedCookie("token")
+		assert.Nil(t, err)
+		_, err = c.SignedCookie("lang")
+		assert.Equal(t, cookie.ErrInvalidSignature, err)
+		_, err = c.Cookie("none")
+		assert.Equal(t, http.ErrNoCookie, err)
+	})
+
+	w := request(router, http.MethodGet, "/set", "")
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 3)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[1].SameSite)
+	assert.True(t, cookies[1].HttpOnly)
+	headers := []header{}
+	for _, c := range cookies {
+		headers = append(headers, header{"Cookie", c.Name + "=" + c.Value})
+	}
+	request(router, http.MethodGet, "/get", "", headers...)
+	assert.Equal(t, map[string]string{"lang": "日本語", "user": "taro", "token": "abc"}, values)
+}
+
+func TestHTML(t *testing.T) {
+	router := bdx.New()
+	assert.Nil(t, router.LoadHTMLGlob("render/testdata/templates/students/raw.html"))
+	router.GET("/", func(c interfaces.Context) {
+		c.HTML(http.StatusOK, "raw.html", bdx.B{"Title": "a&b"})
+	})
+	w := request(router, http.MethodGet, "/", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<p>a&amp;b</p>\n", w.Body.String())
+}
+
+func TestOnStart(t *testing.T) {
+	signature := ""
+	failed := errors.New("failed")
+	router := bdx.New()
+	router.OnStart(func() error {
+		signature += "A"
+		return nil
+	}, func() error {
+		signature += "B"
+		return failed
+	}, func() error {
+		signature += "C"
+		return nil
+	})
+	assert.Equal(t, failed, router.Run(":0"))
+	assert.Equal(t, "AB", signature)
+}
+
+func TestShutdown(t *testing.T) {
+	router := bdx.New()
+	signature := ""
+	router.OnShutdown(func() {
+		signature += "A"
+	})
+	router.GET("/", func(c interfaces.Context) {
+		c.JSON(http.StatusOK, bdx.B{})
+	})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- router.Run("127.0.0.1:0")
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, router.Shutdown(context.Background()))
+	assert.Equal(t, http.ErrServerClosed, <-errc)
+	assert.Equal(t, "A", signature)
+	assert.Equal(t, http.ErrServerClosed, router.Run("127.0.0.1:0"))
+}
+
+func TestShutdownDelay(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	router := bdx.New()
+	router.SetShutdownDelay(200 * time.Millisecond)
+	ready := make(chan struct{})
+	router.OnShutdown(func() {
+		close(ready)
+	})
+	router.GET("/", func(c interfaces.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.WS("/ws", func(c interfaces.Context, conn *websocket.Conn) {
+		conn.ReadMessage()
+	})
+	go router.Run(addr)
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	assert.Nil(t, err)
+	defer conn.Close()
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/ws", nil)
+	req.Header.Set("Origin", "http://"+addr)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Write(conn)
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- router.Shutdown(context.Background())
+	}()
+	<-ready
+	// 待機中は新しいリクエストを処理する
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	res, err = client.Get("http://" + addr + "/")
+	if assert.Nil(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	assert.Nil(t, <-done)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	// WebSocketの接続は1001 Going Awayで閉じる
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	frame := make([]byte, 4)
+	_, err = io.ReadFull(br, frame)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x88, 2, 0x03, 0xe9}, frame)
+}
+
+func TestNegotiate(t *testing.T) {
+	router := bdx.New()
+	assert.Nil(t, router.LoadHTMLGlob("render/testdata/templates/students/raw.html"))
+	router.GET("/", func(c interfaces.Context) {
+		c.Negotiate(http.StatusOK, render.Negotiation{
+			Data:     bdx.B{"Title": "a&b"},
+			HTMLName: "raw.html",
+		})
+	})
+	router.GET("/json", func(c interfaces.Context) {
+		c.Negotiate(http.StatusOK, render.Negotiation{JSON: bdx.B{"id": 1}})
+	})
+
+	w := request(router, http.MethodGet, "/", "", header{"Accept", "text/html,application/xhtml+xml,*/*;q=0.8"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<p>a&amp;b</p>\n", w.Body.String())
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	w = request(router, http.MethodGet, "/", "", header{"Accept", "application/x-yaml;q=0.9, application/json;q=0.5"})
+	assert.Equal(t, "application/x-yaml; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = request(router, http.MethodGet, "/json", "")
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+
+	w = request(router, http.MethodGet, "/json", "", header{"Accept", "text/html"})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	router.SetNegotiateDefault(middleware.MIMEJSON)
+	w = request(router, http.MethodGet, "/json", "", header{"Accept", "text/html"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+}
+
+func TestBinary(t *testing.T) {
+	router := bdx.New()
+	g := router.Group("/v1", middleware.Validator(User{}))
+	g.POST("/users", func(c interfaces.Context) {
+		var u User
+		if err := c.Bind(&u); err != nil {
+			c.AbortWithUnsupportedMediaType()
+			return
+		}
+		c.Negotiate(http.StatusCreated, render.Negotiation{Data: u})
+	})
+	router.POST("/proto", func(c interfaces.Context) {
+		var msg wrapperspb.StringValue
+		if err := c.Bind(&msg); err != nil {
+			c.AbortWithUnsupportedMediaType()
+			return
+		}
+		c.ProtoBuf(http.StatusOK, wrapperspb.String(strings.ToUpper(msg.GetValue())))
+	})
+
+	var body []byte
+	codec.NewEncoderBytes(&body, render.MsgPackHandle).Encode(User{ID: 1, Name: "taro"})
+	w := request(router, http.MethodPost, "/v1/users", string(body), header{"Content-Type", middleware.MIMEMSGPACK}, header{"Accept", middleware.MIMEMSGPACK2})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	var u User
+	assert.Nil(t, codec.NewDecoderBytes(w.Body.Bytes(), render.MsgPackHandle).Decode(&u))
+	assert.Equal(t, User{ID: 1, Name: "taro"}, u)
+
+	w = request(router, http.MethodPost, "/v1/users", string(body), header{"Content-Type", middleware.MIMEMSGPACK})
+	assert.Equal(t, `{"id":1,"name":"taro"}`, w.Body.String())
+
+	body = nil
+	codec.NewEncoderBytes(&body, render.MsgPackHandle).Encode(User{ID: 1})
+	w = request(router, http.MethodPost, "/v1/users", string(body), header{"Content-Type", middleware.MIMEMSGPACK}, header{"Accept", middleware.MIMEMSGPACK})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var res map[string]interface{}
+	assert.Nil(t, codec.NewDecoderBytes(w.Body.Bytes(), render.MsgPackHandle).Decode(&res))
+	assert.Equal(t, "Invalid body parser", res["error"])
+
+	body, _ = proto.Marshal(wrapperspb.String("taro"))
+	w = request(router, http.MethodPost, "/proto", string(body), header{"Content-Type", middleware.MIMEPROTOBUF})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var msg wrapperspb.StringValue
+	assert.Nil(t, proto.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, "TARO", msg.GetValue())
+}
+
+func TestStreamRender(t *testing.T) {
+	router := bdx.New()
+	router.GET("/students.csv", func(c interfaces.Context) {
+		ch := make(chan []string)
+		go func() {
+			defer close(ch)
+			for _, name := range []string{"太郎", "花子"} {
+				ch <- []string{name}
+			}
+		}()
+		c.CSV(http.StatusOK, []string{"氏名"}, render.FromChan(ch))
+	})
+	router.GET("/students", func(c interfaces.Context) {
+		c.NDJSON(http.StatusOK, func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("failed")
+		})
+	})
+
+	w := request(router, http.MethodGet, "/students.csv", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "氏名\n太郎\n花子\n", w.Body.String())
+
+	w = request(router, http.MethodGet, "/students", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestSSE(t *testing.T) {
+	router := bdx.New()
+	router.GET("/events", func(c interfaces.Context) {
+		id, _ := strconv.Atoi(c.LastEventID())
+		c.Stream(func(w io.Writer) bool {
+			id++
+			c.SSE(render.SSE{ID: strconv.Itoa(id), Event: "progress", Data: bdx.B{"id": id}})
+			return id < 3
+		})
+	})
+	router.GET("/raw", func(c interfaces.Context) {
+		c.SSEvent("message", "a\nb")
+		c.Stream(func(w io.Writer) bool {
+			fmt.Fprint(w, "data: c\n\n")
+			return false
+		})
+	})
+
+	w := request(router, http.MethodGet, "/events", "", header{"Last-Event-ID", "1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "id: 2\nevent: progress\ndata: {\"id\":2}\n\nid: 3\nevent: progress\ndata: {\"id\":3}\n\n", w.Body.String())
+
+	w = request(router, http.MethodGet, "/events?lastEventId=2", "")
+	assert.Equal(t, "id: 3\nevent: progress\ndata: {\"id\":3}\n\n", w.Body.String())
+
+	w = request(router, http.MethodGet, "/raw", "")
+	assert.Equal(t, "event: message\ndata: a\ndata: b\n\ndata: c\n\n", w.Body.String())
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	router := bdx.New()
+	router.SetSSEHeartbeat(10 * time.Millisecond)
+	gone := make(chan bool, 1)
+	router.GET("/events", func(c interfaces.Context) {
+		gone <- c.Stream(func(w io.Writer) bool {
+			<-c.Request().Context().Done()
+			return false
+		})
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events")
+	assert.Nil(t, err)
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, ": ping\n", line)
+	res.Body.Close()
+
+	select {
+	case clientGone := <-gone:
+		assert.True(t, clientGone)
+	case <-time.After(time.Second):
+		t.Fatal("クライアントの切断を検知できませんでした")
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	router := bdx.New()
+	auth := func(c interfaces.Context) {
+		if c.Query("token") != "secret" {
+			c.AbortWithStatusAndMessage(http.StatusUnauthorized, nil)
+			return
+		}
+		c.Next()
+	}
+	g := router.Group("/ws")
+	g.Use(auth)
+	g.WS("/echo/:room", func(c interfaces.Context, conn *websocket.Conn) {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, append([]byte(c.Params().ByName("room")+":"), msg...))
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dial := func(path string) (net.Conn, *bufio.Reader, *http.Response) {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		assert.Nil(t, err)
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Write(conn)
+		br := bufio.NewReader(conn)
+		res, err := http.ReadResponse(br, req)
+		assert.Nil(t, err)
+		return conn, br, res
+	}
+
+	conn, _, res := dial("/ws/echo/room1")
+	conn.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	conn, br, res := dial("/ws/echo/room1?token=secret")
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	// マスクしたテキストフレーム "hi"
+	conn.Write([]byte{0x81, 0x82, 1, 2, 3, 4, 'h' ^ 1, 'i' ^ 2})
+	frame := make([]byte, 10)
+	_, err := io.ReadFull(br, frame)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x81, 8}, frame[:2])
+	assert.Equal(t, "room1:hi", string(frame[2:]))
+	// ハンドラが終了すると接続を閉じる
+	frame = make([]byte, 4)
+	_, err = io.ReadFull(br, frame)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x88, 2, 0x03, 0xe8}, frame)
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bdx")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "export.csv")
+	ioutil.WriteFile(src, []byte("id,name\n1,太郎\n"), 0600)
+
+	router := bdx.New()
+	router.SetMaxMultipartMemory(16)
+	tmpfile := ""
+	router.GET("/file", func(c interfaces.Context) {
+		c.File(src)
+	})
+	router.GET("/download", func(c interfaces.Context) {
+		c.FileAttachment(src, "生徒一覧.csv")
+	})
+	router.GET("/reader", func(c interfaces.Context) {
+		c.DataFromReader(http.StatusOK, 5, "text/plain", strings.NewReader("hello"), map[string]string{"X-Source": "reader"})
+	})
+	router.POST("/upload", func(c interfaces.Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.AbortWithStatusAndMessage(http.StatusBadRequest, nil)
+			return
+		}
+		form, _ := c.MultipartForm()
+		if f, err := file.Open(); err == nil {
+			if tmp, ok := f.(*os.File); ok {
+				tmpfile = tmp.Name()
+			}
+			f.Close()
+		}
+		dst := filepath.Join(dir, filepath.Base(file.Filename))
+		if err := c.SaveUploadedFile(file, dst); err != nil {
+			c.AbortWithStatusAndMessage(http.StatusInternalServerError, nil)
+			return
+		}
+		c.JSON(http.StatusCreated, bdx.B{"name": form.Value["name"][0], "size": file.Size})
+	})
+
+	w := request(router, http.MethodGet, "/file", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "id,name\n1,太郎\n", w.Body.String())
+
+	w = request(router, http.MethodGet, "/download", "", header{"Range", "bytes=0-6"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "id,name", w.Body.String())
+	assert.Equal(t, `attachment; filename="____.csv"; filename*=UTF-8''%E7%94%9F%E5%BE%92%E4%B8%80%E8%A6%A7.csv`, w.Header().Get("Content-Disposition"))
+
+	w = request(router, http.MethodGet, "/reader", "")
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+	assert.Equal(t, "reader", w.Header().Get("X-Source"))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "課題")
+	fw, _ := mw.CreateFormFile("file", "../../report.txt")
+	fw.Write([]byte(strings.Repeat("a", 100)))
+	mw.Close()
+	w = request(router, http.MethodPost, "/upload", body.String(), header{"Content-Type", mw.FormDataContentType()})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"name":"課題","size":100}`, w.Body.String())
+	saved, err := ioutil.ReadFile(filepath.Join(dir, "report.txt"))
+	assert.Nil(t, err)
+	assert.Len(t, saved, 100)
+	// `MaxMultipartMemory`を超えて一時ファイルに保存した分はリクエストの終了時に削除する
+	assert.NotEmpty(t, tmpfile)
+	_, err = os.Stat(tmpfile)
+	assert.True(t, os.IsNotExist(err))
+
+	w = request(router, http.MethodPost, "/upload", "{}")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Equal(t, int64(0), router.MaxUploadSize())
+	router.SetMaxUploadSize(64)
+	w = request(router, http.MethodPost, "/upload", body.String(), header{"Content-Type", mw.FormDataContentType()})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTextResponses(t *testing.T) {
+	router := bdx.New()
+	router.GET("/string", func(c interfaces.Context) {
+		c.String(http.StatusOK, "id=%d", 1)
+	})
+	router.GET("/data", func(c interfaces.Context) {
+		c.Data(http.StatusAccepted, "application/octet-stream", []byte{1, 2})
+	})
+	router.GET("/redirect", func(c interfaces.Context) {
+		c.Redirect(http.StatusSeeOther, c.DefaultQuery("to", "/string"))
+	})
+	router.DELETE("/students/:id", func(c interfaces.Context) {
+		c.NoContent(http.StatusNoContent)
+	})
+	router.GET("/not-modified", func(c interfaces.Context) {
+		c.String(http.StatusNotModified, "ignored")
+	})
+
+	w := request(router, http.MethodGet, "/string", "")
+	assert.Equal(t, "id=1", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = request(router, http.MethodGet, "/data", "")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, []byte{1, 2}, w.Body.Bytes())
+
+	w = request(router, http.MethodGet, "/redirect", "")
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/string", w.Header().Get("Location"))
+	assert.Panics(t, func() {
+		request(router, http.MethodGet, "/redirect?to=//evil.example.com", "")
+	})
+
+	w = request(router, http.MethodDelete, "/students/1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "", w.Body.String())
+
+	w = request(router, http.MethodGet, "/not-modified", "")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestJSONVariants(t *testing.T) {
+	router := bdx.New()
+	router.GET("/indented", func(c interfaces.Context) {
+		c.IndentedJSON(http.StatusOK, bdx.B{"id": 1})
+	})
+	router.GET("/secure", func(c interfaces.Context) {
+		c.SecureJSON(http.StatusOK, []int{1})
+	})
+	router.GET("/jsonp", func(c interfaces.Context) {
+		c.JSONP(http.StatusOK, bdx.B{"id": 1})
+	})
+	router.GET("/pure", func(c interfaces.Context) {
+		c.PureJSON(http.StatusOK, bdx.B{"html": "<b>"})
+	})
+
+	w := request(router, http.MethodGet, "/indented", "")
+	assert.Equal(t, "{\n    \"id\": 1\n}", w.Body.String())
+
+	w = request(router, http.MethodGet, "/secure", "")
+	assert.Equal(t, "while(1);[1]", w.Body.String())
+
+	w = request(router, http.MethodGet, "/jsonp?callback=show", "")
+	assert.Equal(t, `/**/show({"id":1});`, w.Body.String())
+	assert.Equal(t, "application/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = request(router, http.MethodGet, "/jsonp", "")
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = request(router, http.MethodGet, "/jsonp?callback=alert(1)", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(router, http.MethodGet, "/pure", "")
+	assert.Equal(t, "{\"html\":\"<b>\"}\n", w.Body.String())
+}
+
+// upperCodec 呼び出されたことが分かるように文字列を大文字にする`render.JSONCodec`
+type upperCodec struct {
+	render.StdJSONCodec
+}
+
+func (c upperCodec) Marshal(v interface{}) ([]byte, error) {
+	buf, err := c.StdJSONCodec.Marshal(v)
+	return bytes.ToUpper(buf), err
+}
+
+func (c upperCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.StdJSONCodec.Unmarshal(bytes.ToUpper(data), v)
+}
+
+func TestJSONCodec(t *testing.T) {
+	handler := func(c interfaces.Context) {
+		var u User
+		if err := c.Bind(&u); err != nil {
+			c.AbortWithUnsupportedMediaType()
+			return
+		}
+		c.JSON(http.StatusOK, bdx.B{"name": u.Name})
+	}
+	custom := bdx.New()
+	custom.SetJSONCodec(upperCodec{})
+	custom.POST("/", handler)
+	std := bdx.New()
+	std.POST("/", handler)
+
+	// Engine毎に設定できる
+	w := request(custom, http.MethodPost, "/", `{"name":"taro"}`)
+	assert.Equal(t, `{"NAME":"TARO"}`, w.Body.String())
+	w = request(std, http.MethodPost, "/", `{"name":"taro"}`)
+	assert.Equal(t, `{"name":"taro"}`, w.Body.String())
+
+	custom.GET("/list", func(c interfaces.Context) {
+		c.Paginated(http.StatusOK, render.Paginated{Data: []string{"a"}, Page: 1, PerPage: 1, Total: 1})
+	})
+	w = request(custom, http.MethodGet, "/list", "")
+	assert.Equal(t, `{"DATA":["A"],"PAGINATION":{"PAGE":1,"PER_PAGE":1,"TOTAL":1,"TOTAL_PAGES":1}}`, w.Body.String())
+	assert.Equal(t, `</list?page=1&per_page=1>; rel="first", </list?page=1&per_page=1>; rel="last"`, w.Result().Header.Get("Link"))
+
+	custom.SetJSONCodec(nil)
+	assert.Equal(t, render.StdJSONCodec{}, custom.JSONCodec())
+}
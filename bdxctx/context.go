@@ -1,14 +1,25 @@
 package bdxctx
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
 	"math"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/binding"
 	"github.com/belldata-dx/bdx/interfaces"
 	"github.com/belldata-dx/bdx/param"
 	"github.com/belldata-dx/bdx/render"
+	"github.com/belldata-dx/bdx/util/conv/datetimeconv"
 )
 
 const abortIndex int8 = math.MaxInt8 / 2
@@ -18,6 +29,7 @@ type (
 	Context struct {
 		request    *http.Request
 		response   http.ResponseWriter
+		writer     responseWriter
 		handlers   interfaces.HandlersChain
 		index      int8
 		err        error
@@ -26,6 +38,8 @@ type (
 		logger     logger.ILogger
 		queryCache url.Values
 		formCache  url.Values
+		// multipartForm 解析したマルチパートフォーム(一時ファイルの削除用)
+		multipartForm *multipart.Form
 	}
 )
 
@@ -63,12 +77,29 @@ func (c *Context) SetLogger(log logger.ILogger) {
 
 // Reset .
 func (c *Context) Reset(response http.ResponseWriter, request *http.Request) {
-	c.response = response
+	if response != &c.writer {
+		c.writer.reset(response)
+	}
+	c.response = &c.writer
 	c.request = request
 	c.index = -1
 	c.handlers = interfaces.HandlersChain{}
 	*c.params = (*c.params)[0:0]
 	c.err = nil
+	c.queryCache = nil
+	c.formCache = nil
+	c.multipartForm = nil
+}
+
+// RemoveMultipartForm 解析したマルチパートフォームの一時ファイルを削除します。
+func (c *Context) RemoveMultipartForm() {
+	if c.multipartForm == nil {
+		return
+	}
+	if err := c.multipartForm.RemoveAll(); err != nil {
+		c.logger.Warnf("マルチパートフォームの一時ファイルの削除エラー: %v", err)
+	}
+	c.multipartForm = nil
 }
 
 // Next は次のミドルウェアもしくはハンドラを実行
@@ -106,6 +137,45 @@ func (c *Context) AbortWithUnsupportedMediaType() {
 	c.Abort()
 }
 
+// SetError ハンドラで発生したエラーを記録
+func (c *Context) SetError(err error) {
+	c.err = err
+}
+
+// Error 記録されたエラー
+func (c *Context) Error() error {
+	return c.err
+}
+
+// BeforeWrite レスポンスヘッダーを書き込む直前に実行する処理を追加します。
+// 後から追加した処理から順に実行します。既に書き込み済みの場合は実行しません。
+// 実行中の`ResponseStatus`は書き込むHTTP response codeを返します。
+//     c.BeforeWrite(func() { c.Response().Header().Set("X-Elapsed", time.Since(start).String()) })
+func (c *Context) BeforeWrite(fn func()) {
+	c.writer.before = append(c.writer.before, fn)
+}
+
+// SetResponseStatus `BeforeWrite`の処理内で、書き込むHTTP response codeを変更します。
+// 変更した場合、ハンドラが書き込むbodyは破棄します。`BeforeWrite`の処理外で呼び出した場合は何もしません。
+//     c.BeforeWrite(func() {
+//         if err := tx.Commit().Error; err != nil {
+//             c.SetResponseStatus(http.StatusInternalServerError)
+//         }
+//     })
+func (c *Context) SetResponseStatus(code int) {
+	c.writer.override = code
+}
+
+// ResponseStatus 書き込まれたHTTP response code(未書き込みの場合は0)
+func (c *Context) ResponseStatus() int {
+	return c.writer.status
+}
+
+// JSONCodec `Engine`に設定されたJSONの実装
+func (c *Context) JSONCodec() render.JSONCodec {
+	return c.engine.JSONCodec()
+}
+
 // Params URIパス パラメータ
 func (c *Context) Params() param.Params {
 	return *c.params
@@ -116,6 +186,92 @@ func (c *Context) SetParams(params *param.Params) {
 	c.params = params
 }
 
+// Cookie リクエストのCookieの値を返します。存在しない場合は`http.ErrNoCookie`を返します。
+func (c *Context) Cookie(name string) (string, error) {
+	ck, err := c.request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(ck.Value)
+}
+
+// SetCookie `Engine.CookieConfig()`の属性でCookieを設定します。
+// `maxAge`が負の値の場合は削除、0の場合はセッションCookieになります。
+//     c.SetCookie("lang", "ja", 3600)
+func (c *Context) SetCookie(name, value string, maxAge int) {
+	http.SetCookie(c.response, c.engine.CookieConfig().New(name, value, maxAge))
+}
+
+// SignedCookie `SetSignedCookie`で設定したCookieを検証して値を返します。
+// 署名が一致しない場合は`cookie.ErrInvalidSignature`を返します。
+func (c *Context) SignedCookie(name string) (string, error) {
+	val, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.engine.CookieConfig().Verify(name, val)
+}
+
+// SetSignedCookie `Engine.CookieConfig()`の鍵で署名したCookieを設定します。
+// 値は改ざんを検知できますが、クライアントから読み取れます。
+func (c *Context) SetSignedCookie(name, value string, maxAge int) error {
+	signed, err := c.engine.CookieConfig().Sign(name, value)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(name, signed, maxAge)
+	return nil
+}
+
+// EncryptedCookie `SetEncryptedCookie`で設定したCookieを復号して値を返します。
+// 復号できない場合は`cookie.ErrDecrypt`を返します。
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	val, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.engine.CookieConfig().Decrypt(name, val)
+}
+
+// SetEncryptedCookie `Engine.CookieConfig()`の鍵で暗号化したCookieを設定します。
+func (c *Context) SetEncryptedCookie(name, value string, maxAge int) error {
+	encrypted, err := c.engine.CookieConfig().Encrypt(name, value)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(name, encrypted, maxAge)
+	return nil
+}
+
+// ParamInt パスパラメータを`int`で返します。
+//     /students/:id  GET /students/1
+//     id, err := c.ParamInt("id")
+func (c *Context) ParamInt(name string) (int, error) {
+	return c.Params().Int(name)
+}
+
+// ParamInt64 パスパラメータを`int64`で返します。
+func (c *Context) ParamInt64(name string) (int64, error) {
+	return c.Params().Int64(name)
+}
+
+// ParamUUID パスパラメータをUUIDとして検証し、小文字に正規化して返します。
+func (c *Context) ParamUUID(name string) (string, error) {
+	return c.Params().UUID(name)
+}
+
+// ParamBool パスパラメータを`bool`で返します。
+func (c *Context) ParamBool(name string) (bool, error) {
+	return c.Params().Bool(name)
+}
+
+// ParamTime パスパラメータを`layout`の書式で`time.Time`に変換して返します。
+//     /reports/:date  GET /reports/2020-08-01
+//     date, err := c.ParamTime("date", datetimeconv.YYYYMMDDHyphen)
+func (c *Context) ParamTime(name string, layout datetimeconv.DateTimeFormat) (time.Time, error) {
+	return c.Params().Time(name, layout)
+}
+
 // SetHandler ハンドラー設定
 func (c *Context) SetHandler(handlers interfaces.HandlersChain) {
 	c.handlers = handlers
@@ -133,10 +289,11 @@ func (c *Context) Status(code int) {
 
 // Render HTTTP response codeと`render.Render`にrender dataを書き込みます
 func (c *Context) Render(code int, r render.Render) {
+	// WriteHeaderの後に設定したヘッダーは送信されないため、先にContentTypeを書き込む
+	r.WriteContentType(c.response)
 	c.Status(code)
 
 	if !bodyAllowedForStatus(code) {
-		r.WriteContentType(c.response)
 		return
 	}
 
@@ -147,7 +304,64 @@ func (c *Context) Render(code int, r render.Render) {
 
 // JSON JSONでHTTP responseを書き込み
 func (c *Context) JSON(code int, data interface{}) {
-	c.Render(code, render.JSON{Data: data})
+	c.Render(code, render.JSON{Data: data, Codec: c.JSONCodec()})
+}
+
+// IndentedJSON インデントしたJSONでHTTP responseを書き込み
+// 本文が大きくなるため、デバッグ用途以外では`JSON`を使用してください。
+func (c *Context) IndentedJSON(code int, data interface{}) {
+	c.Render(code, render.IndentedJSON{Data: data, Codec: c.JSONCodec()})
+}
+
+// SecureJSON JSONハイジャッキング対策の接頭辞を付けたJSONでHTTP responseを書き込み
+// `data`が配列の場合のみ`while(1);`を先頭に付けます。
+func (c *Context) SecureJSON(code int, data interface{}) {
+	c.Render(code, render.SecureJSON{Data: data, Codec: c.JSONCodec()})
+}
+
+// JSONP クエリパラメータ`callback`の関数呼び出しでHTTP responseを書き込み
+// `callback`が無い場合はJSONを、不正な場合は400 Bad Requestを返します。
+//     GET /students?callback=showStudents
+//     /**/showStudents([{"id":1}]);
+func (c *Context) JSONP(code int, data interface{}) {
+	callback := c.Query("callback")
+	if callback != "" && !render.ValidCallback(callback) {
+		c.AbortWithStatusAndMessage(http.StatusBadRequest, nil)
+		return
+	}
+	c.Render(code, render.JSONP{Callback: callback, Data: data, Codec: c.JSONCodec()})
+}
+
+// PureJSON `<`、`>`、`&`をエスケープしないJSONでHTTP responseを書き込み
+func (c *Context) PureJSON(code int, data interface{}) {
+	c.Render(code, render.PureJSON{Data: data, Codec: c.JSONCodec()})
+}
+
+// Paginated ページング付きのJSONでHTTP responseを書き込み
+// `URL`が未指定の場合はリクエストのURLを基準に`Link`ヘッダーを書き込みます。
+//     c.Paginated(http.StatusOK, render.Paginated{Data: students, Page: q.Page, PerPage: q.PerPage, Total: total})
+func (c *Context) Paginated(code int, p render.Paginated) {
+	if p.Codec == nil {
+		p.Codec = c.JSONCodec()
+	}
+	if p.URL == nil {
+		p.URL = c.request.URL
+	}
+	c.Render(code, p)
+}
+
+// HTML `Engine`に登録されたテンプレートでHTTP responseを書き込み
+//     c.HTML(http.StatusOK, "students/index.html", bdx.B{"Students": students})
+func (c *Context) HTML(code int, name string, data interface{}) {
+	templates := c.engine.HTMLTemplates()
+	if templates == nil {
+		panic("HTMLテンプレートが登録されていません。")
+	}
+	r, err := templates.Instance(name, data)
+	if err != nil {
+		panic(err)
+	}
+	c.Render(code, r)
 }
 
 // XML XMLでHTTP responseを書き込み
@@ -160,6 +374,364 @@ func (c *Context) YAML(code int, data interface{}) {
 	c.Render(code, render.YAML{Data: data})
 }
 
+// String `format`に`values`を埋め込んだ文字列をHTTP responseとして書き込み
+//     c.String(http.StatusOK, "こんにちは、%sさん", name)
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.Render(code, render.String{Format: format, Data: values})
+}
+
+// Data `contentType`で`data`をHTTP responseとして書き込み
+func (c *Context) Data(code int, contentType string, data []byte) {
+	c.Render(code, render.Data{ContentType: contentType, Data: data})
+}
+
+// Redirect `location`へリダイレクトします。
+// `code`が3xx(または201)ではない場合と、`location`が別のホストへ遷移する相対URLの場合はpanicします。
+//     c.Redirect(http.StatusSeeOther, "/students/"+id)
+func (c *Context) Redirect(code int, location string) {
+	// `http.Redirect`がステータスを書き込むため、`Render`を経由しない
+	if err := (render.Redirect{Code: code, Request: c.request, Location: location}).Render(c.response); err != nil {
+		panic(err)
+	}
+}
+
+// NoContent 本文の無いHTTP responseを書き込み
+//     c.NoContent(http.StatusNoContent)
+func (c *Context) NoContent(code int) {
+	c.Status(code)
+}
+
+// ProtoBuf Protocol BuffersでHTTP responseを書き込み
+// `data`は`proto.Message`である必要があります。
+func (c *Context) ProtoBuf(code int, data interface{}) {
+	c.Render(code, render.ProtoBuf{Data: data})
+}
+
+// MsgPack MessagePackでHTTP responseを書き込み
+func (c *Context) MsgPack(code int, data interface{}) {
+	c.Render(code, render.MsgPack{Data: data})
+}
+
+// File `filepath`のファイルをHTTP responseとして書き込み
+// `Range`と`If-Modified-Since`のリクエストに対応します。
+func (c *Context) File(filepath string) {
+	http.ServeFile(c.response, c.request, filepath)
+}
+
+// FileAttachment `filepath`のファイルを`filename`のファイル名でダウンロードさせます。
+// ASCII以外のファイル名はRFC 6266の`filename*`でUTF-8として送信します。
+//     c.FileAttachment("/var/exports/2020.csv", "生徒一覧_2020年度.csv")
+func (c *Context) FileAttachment(filepath, filename string) {
+	c.response.Header().Set("Content-Disposition", render.ContentDisposition("attachment", filename))
+	http.ServeFile(c.response, c.request, filepath)
+}
+
+// DataFromReader `reader`の内容をHTTP responseとして書き込み
+// `contentLength`が負の値の場合は`Content-Length`ヘッダーを設定しません。
+//     c.DataFromReader(http.StatusOK, obj.Size, "application/pdf", obj.Body, map[string]string{
+//         "Content-Disposition": render.ContentDisposition("attachment", obj.Name),
+//     })
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	c.RenderStream(code, render.Reader{
+		ContentType:   contentType,
+		ContentLength: contentLength,
+		Reader:        reader,
+		Headers:       extraHeaders,
+	})
+}
+
+// MultipartForm マルチパートフォームを解析して返します。
+// `Engine.MaxMultipartMemory()`を超えたファイルは一時ファイルに保存し、リクエストの終了時に削除します。
+// リクエストボディが`Engine.MaxUploadSize()`を超えた場合はエラーを返します。
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+	return c.request.MultipartForm, nil
+}
+
+// FormFile マルチパートフォームの`name`の最初のファイルを返します。
+//     file, err := c.FormFile("avatar")
+//     if err != nil {
+//         c.AbortWithStatusAndMessage(http.StatusBadRequest, nil)
+//         return
+//     }
+//     c.SaveUploadedFile(file, filepath.Join(dir, filepath.Base(file.Filename)))
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+	f, fh, err := c.request.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return fh, nil
+}
+
+// SaveUploadedFile アップロードされたファイルを`dst`に保存します。
+// `file.Filename`はクライアントが指定した値のため、そのままパスに使用しないでください。
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// parseMultipartForm `Engine.MaxUploadSize()`までのリクエストボディをマルチパートフォームとして解析します。
+func (c *Context) parseMultipartForm() error {
+	req := c.request
+	if req.MultipartForm != nil {
+		return nil
+	}
+	if max := c.engine.MaxUploadSize(); max > 0 && req.Body != nil {
+		req.Body = http.MaxBytesReader(c.response, req.Body, max)
+	}
+	err := req.ParseMultipartForm(c.engine.MaxMultipartMemory())
+	if req.MultipartForm != nil {
+		c.multipartForm = req.MultipartForm
+	}
+	return err
+}
+
+// NDJSON `rows`の各行を改行区切りのJSONで順次書き込み
+// クライアントが切断した場合は書き込みを中断します。
+//     c.NDJSON(http.StatusOK, render.FromChan(ch))
+func (c *Context) NDJSON(code int, rows render.Rows) {
+	c.RenderStream(code, render.NDJSON{Context: c.request.Context(), Rows: rows, Codec: c.JSONCodec()})
+}
+
+// JSONArray `rows`の各行をJSON配列の要素として順次書き込み
+// クライアントが切断した場合は書き込みを中断します。
+func (c *Context) JSONArray(code int, rows render.Rows) {
+	c.RenderStream(code, render.JSONArray{Context: c.request.Context(), Rows: rows, Codec: c.JSONCodec()})
+}
+
+// CSV `header`と`rows`の各行をCSVで順次書き込み
+// 区切り文字やBOMを指定する場合は`render.CSV`を`RenderStream`へ渡してください。
+//     c.RenderStream(http.StatusOK, render.CSV{Context: c.Request().Context(), Header: header, Rows: rows, BOM: true})
+func (c *Context) CSV(code int, header []string, rows render.Rows) {
+	c.RenderStream(code, render.CSV{Context: c.request.Context(), Header: header, Rows: rows})
+}
+
+// RenderStream `Render`と同じですが、ステータスを送信済みのため書き込み中のエラーはpanicせずにログへ出力し、`SetError`で記録します。
+func (c *Context) RenderStream(code int, r render.Render) {
+	r.WriteContentType(c.response)
+	c.Status(code)
+	if !bodyAllowedForStatus(code) {
+		return
+	}
+	if err := r.Render(c.response); err != nil {
+		c.logger.Errorf("ストリーミング中にエラーが発生しました: %v", err)
+		c.SetError(err)
+	}
+}
+
+// SSEvent Server-Sent Eventsのイベントを書き込み、フラッシュします。
+// `data`が文字列以外の場合はJSONにマーシャルします。
+//     c.SSEvent("progress", bdx.B{"percent": 50})
+func (c *Context) SSEvent(event string, data interface{}) {
+	c.SSE(render.SSE{Event: event, Data: data})
+}
+
+// SSE `render.SSE`のイベントを書き込み、フラッシュします。
+// 再接続時に`LastEventID`で再開できるように`ID`を設定してください。
+// `Codec`を省略した場合は`Engine`に設定されたJSONの実装を使用します。
+//     c.SSE(render.SSE{ID: strconv.Itoa(job.Seq), Event: "progress", Data: job})
+func (c *Context) SSE(e render.SSE) {
+	if e.Codec == nil {
+		e.Codec = c.JSONCodec()
+	}
+	c.sseHeader()
+	if err := e.Render(c.response); err != nil {
+		c.logger.Errorf("SSEの書き込み中にエラーが発生しました: %v", err)
+		c.SetError(err)
+		return
+	}
+	c.flush()
+}
+
+// Stream `step`が`false`を返すかクライアントが切断するまで`step`を繰り返し実行します。
+// `step`で書き込んだ内容は`step`が返るたびにまとめて書き込み、フラッシュします。
+// SSEのレスポンスでは`Engine.SSEHeartbeat()`の間隔でハートビートを送信します。
+// クライアントの切断は`Request().Context()`で検知し、切断した場合は`true`を返します。
+// `step`で待機する場合は`Request().Context().Done()`も待機してください。
+//     c.Stream(func(w io.Writer) bool {
+//         select {
+//         case p, ok := <-progress:
+//             if !ok {
+//                 return false
+//             }
+//             c.SSEvent("progress", p)
+//             return true
+//         case <-c.Request().Context().Done():
+//             return false
+//         }
+//     })
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	ctx := c.request.Context()
+	// ハートビートと並行してヘッダーを変更しないように、先にヘッダーを送信する
+	if c.writer.status == 0 {
+		if c.response.Header().Get("Content-Type") == "" {
+			c.sseHeader()
+		}
+		c.Status(http.StatusOK)
+		c.flush()
+	}
+	if interval := c.engine.SSEHeartbeat(); interval > 0 && strings.HasPrefix(c.response.Header().Get("Content-Type"), "text/event-stream") {
+		stop := c.heartbeat(ctx, interval)
+		defer stop()
+	}
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		default:
+		}
+		buf.Reset()
+		keep := step(&buf)
+		if buf.Len() > 0 {
+			if _, err := c.response.Write(buf.Bytes()); err != nil {
+				return true
+			}
+		}
+		c.flush()
+		if !keep {
+			return ctx.Err() != nil
+		}
+	}
+}
+
+// LastEventID 再接続したクライアントが最後に受信したイベントのID
+// `Last-Event-ID`ヘッダーが無い場合は`lastEventId`クエリパラメータを返します。
+func (c *Context) LastEventID() string {
+	if id := c.request.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("lastEventId")
+}
+
+// sseHeader レスポンスヘッダーが未送信であればSSEのヘッダーを設定します。
+func (c *Context) sseHeader() {
+	if c.writer.status != 0 {
+		return
+	}
+	header := c.response.Header()
+	render.SSE{}.WriteContentType(c.response)
+	header.Set("Cache-Control", "no-cache")
+	// nginxのバッファリングを無効にする
+	header.Set("X-Accel-Buffering", "no")
+}
+
+// heartbeat `interval`ごとにハートビートを送信し、停止する関数を返します。
+func (c *Context) heartbeat(ctx context.Context, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if render.SSEPing(c.response) != nil {
+					return
+				}
+				c.flush()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (c *Context) flush() {
+	if f, ok := c.response.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Bind リクエストボディを`Content-Type`に応じてデコードし、`obj`に設定します。
+// JSON、XML、YAML、Protocol Buffers、MessagePackに対応し、それ以外は`binding.ErrUnsupportedMediaType`を返します。
+// 読み込んだボディは再度読み込めるように`Request().Body`へ戻します。
+//     var s Student
+//     if err := c.Bind(&s); err != nil {
+//         c.AbortWithUnsupportedMediaType()
+//         return
+//     }
+func (c *Context) Bind(obj interface{}) error {
+	b, ok := binding.Default(c.request.Header.Get("Content-Type"))
+	if !ok {
+		return binding.ErrUnsupportedMediaType
+	}
+	if b == binding.JSON {
+		b = binding.JSONWith(c.JSONCodec())
+	}
+	body, err := ioutil.ReadAll(c.request.Body)
+	if err != nil {
+		return err
+	}
+	c.request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return b.Decode(body, obj)
+}
+
+// NegotiateFormat `offered`の中から`Accept`ヘッダーで最も優先される形式を返します。
+// 一致する形式が無い場合は`Engine.NegotiateDefault()`が`offered`に含まれていればそれを、含まれていなければ空文字を返します。
+//     Accept: text/html;q=0.9, application/json
+//     c.NegotiateFormat(middleware.MIMEHTML, middleware.MIMEJSON) == "application/json"
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if format := render.NegotiateFormat(c.request.Header.Get("Accept"), offered...); format != "" {
+		return format
+	}
+	def := c.engine.NegotiateDefault()
+	for _, o := range offered {
+		if def != "" && o == def {
+			return def
+		}
+	}
+	return ""
+}
+
+// Negotiate `Accept`ヘッダーで選択した形式でHTTP responseを書き込み
+// 一致する形式が無い場合は406 Not Acceptableを返して終了します。
+//     c.Negotiate(http.StatusOK, render.Negotiation{
+//         Data:     students,
+//         HTMLName: "students/index.html",
+//     })
+func (c *Context) Negotiate(code int, n render.Negotiation) {
+	if n.Codec == nil {
+		n.Codec = c.JSONCodec()
+	}
+	c.response.Header().Add("Vary", "Accept")
+	r, err := n.Instance(c.NegotiateFormat(n.NegotiationOffered()...), c.engine.HTMLTemplates())
+	if err == render.ErrNotAcceptable {
+		c.AbortWithStatusAndMessage(http.StatusNotAcceptable, nil)
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	c.Render(code, r)
+}
+
 // Query url parameterが存在すればそれを返します。
 // 存在しない場合は空文字を返します。
 // これは`c.Request.URL.Query().Get(key)`のショートカットと同じです。
@@ -224,8 +796,7 @@ func (c *Context) initQuery() {
 func (c *Context) initPostForm() {
 	if c.formCache == nil {
 		c.formCache = make(url.Values)
-		req := c.request
-		if err := req.ParseMultipartForm(c.engine.MaxMultipartMemory()); err != nil {
+		if err := c.parseMultipartForm(); err != nil {
 			c.logger.Debugf("マルチパート形式の配列を解析する際のエラー: %v", err)
 		}
 		c.formCache = c.request.PostForm
@@ -1,10 +1,14 @@
 package bdxctx_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
+	"github.com/belldata-dx/bdx/bdxctx"
 	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/param"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,3 +40,16 @@ func TestHandlersChainNotEqual(t *testing.T) {
 func TestContext(t *testing.T) {
 	// ctx := bdxctx.New(nil, nil)
 }
+
+func TestResetClearsCache(t *testing.T) {
+	params := make(param.Params, 0)
+	c := bdxctx.New(nil, &params)
+
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?name=taro", nil))
+	assert.Equal(t, "taro", c.Query("name"))
+
+	// プールから再利用されたContextが前のリクエストの値を返さないこと
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?grade=1", nil))
+	assert.Equal(t, "", c.Query("name"))
+	assert.Equal(t, "1", c.Query("grade"))
+}
@@ -0,0 +1,114 @@
+package bdxctx
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// responseWriter 書き込まれたHTTP response codeとサイズを記録する`http.ResponseWriter`
+// `Context.Stream`のハートビートと並行して書き込むため、書き込みは排他制御します。
+type responseWriter struct {
+	http.ResponseWriter
+	mu     sync.Mutex
+	status int
+	size   int
+	before []func()
+	// override `before`の実行中に変更されたHTTP response code
+	override int
+	// discard HTTP response codeが変更されたため、bodyを書き込まない
+	discard bool
+}
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = 0
+	w.size = 0
+	w.before = nil
+	w.override = 0
+	w.discard = false
+}
+
+// writeStatus HTTP response codeを記録し、最初の書き込みの前に`before`を実行します
+// `before`の実行中は`status`が書き込むHTTP response codeを返します。
+// `before`が`override`でHTTP response codeを変更した場合は、以降のbodyを破棄します。
+func (w *responseWriter) writeStatus(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	w.override = 0
+	before := w.before
+	w.before = nil
+	for i := len(before) - 1; i >= 0; i-- {
+		before[i]()
+	}
+	if w.override != 0 && w.override != code {
+		w.status = w.override
+		w.discard = true
+	}
+	w.override = 0
+}
+
+// writeImplicitStatus `Write`、`Flush`で暗黙に200を書き込みます
+func (w *responseWriter) writeImplicitStatus() {
+	if w.status != 0 {
+		return
+	}
+	w.writeStatus(http.StatusOK)
+	if w.discard {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// WriteHeader HTTP response codeを記録して書き込みます
+func (w *responseWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		w.writeStatus(code)
+		code = w.status
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 書き込まれたサイズを記録します
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeImplicitStatus()
+	if w.discard {
+		return len(data), nil
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+// Flush `http.Flusher`
+func (w *responseWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeImplicitStatus()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack `http.Hijacker`
+// ハイジャックした後はHTTP response codeを101 Switching Protocolsとして記録します。
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("bdxctx: http.ResponseWriterがhttp.Hijackerを実装していません")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeStatus(http.StatusSwitchingProtocols)
+	if w.discard {
+		return nil, nil, errors.New("bdxctx: BeforeWriteでHTTP response codeが変更されたため、ハイジャックできません")
+	}
+	return h.Hijack()
+}
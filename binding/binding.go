@@ -0,0 +1,130 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// Content-Type MIME of the binary data formats.
+const (
+	MIMEPROTOBUF = "application/x-protobuf"
+	MIMEMSGPACK  = "application/x-msgpack"
+	MIMEMSGPACK2 = "application/msgpack"
+)
+
+type (
+	// Binding リクエストボディのデコーダ
+	Binding interface {
+		// Name デコーダの名前
+		Name() string
+		// Decode `body`をデコードして`obj`に設定します。
+		Decode(body []byte, obj interface{}) error
+	}
+
+	jsonBinding     struct{ codec render.JSONCodec }
+	xmlBinding      struct{}
+	yamlBinding     struct{}
+	protobufBinding struct{}
+	msgpackBinding  struct{}
+)
+
+var (
+	// ErrUnsupportedMediaType 対応するデコーダが無いContent-Type
+	ErrUnsupportedMediaType = errors.New("binding: 対応していないContent-Typeです")
+	// ErrNotProtoMessage `ProtoBuf`のデコード先が`proto.Message`ではない
+	ErrNotProtoMessage = errors.New("binding: デコード先がproto.Messageではありません")
+)
+
+var (
+	JSON     Binding = jsonBinding{}
+	XML      Binding = xmlBinding{}
+	YAML     Binding = yamlBinding{}
+	ProtoBuf Binding = protobufBinding{}
+	MsgPack  Binding = msgpackBinding{}
+)
+
+// JSONWith `codec`でデコードするJSONのデコーダを返します。
+// `JSON`は`encoding/json`でデコードします。
+//     b := binding.JSONWith(c.JSONCodec())
+func JSONWith(codec render.JSONCodec) Binding {
+	return jsonBinding{codec: codec}
+}
+
+// Default `Content-Type`に対応するデコーダを返します。
+// 対応するデコーダが無い場合は`false`を返します。
+//     b, ok := binding.Default(r.Header.Get("Content-Type"))
+func Default(contentType string) (Binding, bool) {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	switch strings.ToLower(strings.TrimSpace(contentType)) {
+	case "application/json":
+		return JSON, true
+	case "application/xml", "text/xml":
+		return XML, true
+	case "application/x-yaml":
+		return YAML, true
+	case MIMEPROTOBUF:
+		return ProtoBuf, true
+	case MIMEMSGPACK, MIMEMSGPACK2:
+		return MsgPack, true
+	default:
+		return nil, false
+	}
+}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (b jsonBinding) Decode(body []byte, obj interface{}) error {
+	if b.codec == nil {
+		return json.Unmarshal(body, obj)
+	}
+	return b.codec.Unmarshal(body, obj)
+}
+
+func (xmlBinding) Name() string {
+	return "xml"
+}
+
+func (xmlBinding) Decode(body []byte, obj interface{}) error {
+	return xml.Unmarshal(body, obj)
+}
+
+func (yamlBinding) Name() string {
+	return "yaml"
+}
+
+func (yamlBinding) Decode(body []byte, obj interface{}) error {
+	return yaml.Unmarshal(body, obj)
+}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+// Decode `obj`は`proto.Message`である必要があります。
+func (protobufBinding) Decode(body []byte, obj interface{}) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(body, msg)
+}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+func (msgpackBinding) Decode(body []byte, obj interface{}) error {
+	return codec.NewDecoder(bytes.NewReader(body), render.MsgPackHandle).Decode(obj)
+}
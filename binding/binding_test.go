@@ -0,0 +1,63 @@
+package binding_test
+
+import (
+	"testing"
+
+	"github.com/belldata-dx/bdx/binding"
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type student struct {
+	ID   int    `json:"id" xml:"id" yaml:"id"`
+	Name string `json:"name" xml:"name" yaml:"name"`
+}
+
+func TestDefault(t *testing.T) {
+	tests := map[string]binding.Binding{
+		"application/json; charset=utf-8": binding.JSON,
+		"text/xml":                        binding.XML,
+		"application/xml":                 binding.XML,
+		"application/x-yaml":              binding.YAML,
+		"application/x-protobuf":          binding.ProtoBuf,
+		"application/x-msgpack":           binding.MsgPack,
+		"Application/MsgPack":             binding.MsgPack,
+	}
+	for contentType, want := range tests {
+		b, ok := binding.Default(contentType)
+		assert.True(t, ok, contentType)
+		assert.Equal(t, want, b, contentType)
+	}
+	_, ok := binding.Default("text/plain")
+	assert.False(t, ok)
+}
+
+func TestDecode(t *testing.T) {
+	want := student{ID: 1, Name: "太郎"}
+	bodies := map[binding.Binding]string{
+		binding.JSON: `{"id":1,"name":"太郎"}`,
+		binding.XML:  `<student><id>1</id><name>太郎</name></student>`,
+		binding.YAML: "id: 1\nname: 太郎\n",
+	}
+	for b, body := range bodies {
+		var s student
+		assert.Nil(t, b.Decode([]byte(body), &s), b.Name())
+		assert.Equal(t, want, s, b.Name())
+	}
+
+	var buf []byte
+	assert.Nil(t, codec.NewEncoderBytes(&buf, render.MsgPackHandle).Encode(want))
+	var s student
+	assert.Nil(t, binding.MsgPack.Decode(buf, &s))
+	assert.Equal(t, want, s)
+
+	buf, err := proto.Marshal(wrapperspb.String("太郎"))
+	assert.Nil(t, err)
+	var msg wrapperspb.StringValue
+	assert.Nil(t, binding.ProtoBuf.Decode(buf, &msg))
+	assert.Equal(t, "太郎", msg.GetValue())
+	assert.Equal(t, binding.ErrNotProtoMessage, binding.ProtoBuf.Decode(buf, &s))
+}
@@ -0,0 +1,159 @@
+package cookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrNoKey 署名・暗号化の鍵が設定されていない
+	ErrNoKey = errors.New("Cookieの署名・暗号化の鍵が設定されていません。")
+	// ErrInvalidSignature 署名が一致しない
+	ErrInvalidSignature = errors.New("Cookieの署名が一致しません。")
+	// ErrDecrypt 復号できない
+	ErrDecrypt = errors.New("Cookieを復号できません。")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Config Cookieのデフォルト属性と署名・暗号化の鍵
+type Config struct {
+	Path     string
+	Domain   string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+	// Keys 署名・暗号化の鍵
+	//
+	// 先頭の鍵で署名・暗号化し、全ての鍵で検証・復号します。
+	// 鍵を入れ替える場合は新しい鍵を先頭に追加し、古い鍵は有効期限が切れるまで残してください。
+	Keys [][]byte
+}
+
+// DefaultConfig デフォルトの属性(`Path=/`、`HttpOnly`、`SameSite=Lax`)
+func DefaultConfig() Config {
+	return Config{
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// New デフォルトの属性でCookieを生成します。
+// `maxAge`が負の値の場合は削除、0の場合はセッションCookieになります。
+func (cfg Config) New(name, value string, maxAge int) *http.Cookie {
+	path := cfg.Path
+	if path == "" {
+		path = "/"
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   cfg.Domain,
+		Secure:   cfg.Secure,
+		HttpOnly: cfg.HttpOnly,
+		SameSite: cfg.SameSite,
+	}
+}
+
+func mac(key []byte, name, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	io.WriteString(h, name)
+	h.Write([]byte{0})
+	io.WriteString(h, value)
+	return h.Sum(nil)
+}
+
+// Sign `name`と`value`に対するHMAC-SHA256の署名を付与します。
+func (cfg Config) Sign(name, value string) (string, error) {
+	if len(cfg.Keys) == 0 {
+		return "", ErrNoKey
+	}
+	return encoding.EncodeToString([]byte(value)) + "." + encoding.EncodeToString(mac(cfg.Keys[0], name, value)), nil
+}
+
+// Verify `Sign`で署名した値を検証し、元の値を返します。
+func (cfg Config) Verify(name, signed string) (string, error) {
+	if len(cfg.Keys) == 0 {
+		return "", ErrNoKey
+	}
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", ErrInvalidSignature
+	}
+	value, err := encoding.DecodeString(signed[:i])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	sig, err := encoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	for _, key := range cfg.Keys {
+		if hmac.Equal(sig, mac(key, name, string(value))) {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+func gcm(key []byte) (cipher.AEAD, error) {
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt `value`をAES-256-GCMで暗号化します。`name`は改ざん検知の対象に含まれます。
+func (cfg Config) Encrypt(name, value string) (string, error) {
+	if len(cfg.Keys) == 0 {
+		return "", ErrNoKey
+	}
+	aead, err := gcm(cfg.Keys[0])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), []byte(name))), nil
+}
+
+// Decrypt `Encrypt`で暗号化した値を復号します。
+func (cfg Config) Decrypt(name, encrypted string) (string, error) {
+	if len(cfg.Keys) == 0 {
+		return "", ErrNoKey
+	}
+	buf, err := encoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	for _, key := range cfg.Keys {
+		aead, err := gcm(key)
+		if err != nil {
+			return "", err
+		}
+		if len(buf) < aead.NonceSize() {
+			return "", ErrDecrypt
+		}
+		nonce, ciphertext := buf[:aead.NonceSize()], buf[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrDecrypt
+}
@@ -0,0 +1,63 @@
+package cookie_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/belldata-dx/bdx/cookie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	cfg := cookie.Config{Keys: [][]byte{[]byte("old")}}
+	signed, err := cfg.Sign("user", "taro")
+	assert.Nil(t, err)
+	val, err := cfg.Verify("user", signed)
+	assert.Nil(t, err)
+	assert.Equal(t, "taro", val)
+
+	_, err = cfg.Verify("admin", signed)
+	assert.Equal(t, cookie.ErrInvalidSignature, err)
+	_, err = cfg.Verify("user", strings.Replace(signed, "dGFybw", "amlybw", 1))
+	assert.Equal(t, cookie.ErrInvalidSignature, err)
+
+	rotated := cookie.Config{Keys: [][]byte{[]byte("new"), []byte("old")}}
+	val, err = rotated.Verify("user", signed)
+	assert.Nil(t, err)
+	assert.Equal(t, "taro", val)
+	resigned, _ := rotated.Sign("user", "taro")
+	_, err = cfg.Verify("user", resigned)
+	assert.Equal(t, cookie.ErrInvalidSignature, err)
+
+	_, err = cookie.Config{}.Sign("user", "taro")
+	assert.Equal(t, cookie.ErrNoKey, err)
+}
+
+func TestEncrypt(t *testing.T) {
+	cfg := cookie.Config{Keys: [][]byte{[]byte("old")}}
+	encrypted, err := cfg.Encrypt("token", "秘密")
+	assert.Nil(t, err)
+	assert.NotContains(t, encrypted, "秘密")
+	val, err := cfg.Decrypt("token", encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, "秘密", val)
+
+	_, err = cfg.Decrypt("other", encrypted)
+	assert.Equal(t, cookie.ErrDecrypt, err)
+	_, err = cfg.Decrypt("token", "short")
+	assert.Equal(t, cookie.ErrDecrypt, err)
+
+	rotated := cookie.Config{Keys: [][]byte{[]byte("new"), []byte("old")}}
+	val, err = rotated.Decrypt("token", encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, "秘密", val)
+}
+
+func TestNew(t *testing.T) {
+	cfg := cookie.DefaultConfig()
+	cfg.Secure = true
+	c := cfg.New("lang", "ja jp", 60)
+	assert.Equal(t, "lang=ja+jp; Path=/; Max-Age=60; HttpOnly; Secure; SameSite=Lax", c.String())
+	assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+}
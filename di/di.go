@@ -2,48 +2,113 @@ package di
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/belldata-dx/bdx/infra"
 )
 
-var cache = make(map[interface{}]reflect.Value)
-
 type (
 	// Definition モジュール名と生成の方法の構造体
 	Definition struct {
-		Name    interface{}
-		Builder interface{}
-		DiName  []interface{}
+		Name     interface{}
+		Builder  interface{}
+		DiName   []interface{}
+		Lifetime Lifetime
+
+		built     bool
+		builtAt   time.Time
+		buildTime time.Duration
+
+		// mu Singletonのモジュールを一度だけ生成するためのロック
+		mu    sync.Mutex
+		value reflect.Value
 	}
 	// Container DI Container
 	Container struct {
 		definitions map[interface{}]*Definition
+		// mu `Graph`と並行して書き込まれる生成の記録(`built`など)を保護します。
+		mu sync.RWMutex
 	}
 )
 
 type DiType int
 
+// Lifetime モジュールの生存期間
+type Lifetime int
+
+const (
+	// Singleton 一度生成したモジュールを使い回す(デフォルト)
+	Singleton Lifetime = iota
+	// Transient 取り出す度にモジュールを生成する
+	Transient
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	default:
+		return "unknown"
+	}
+}
+
 const (
 	DB DiType = 0
 )
 
 var diType = 0
 
+func (t DiType) String() string {
+	if t == DB {
+		return "DB"
+	}
+	return fmt.Sprintf("DiType(%d)", int(t))
+}
+
 func Increment() DiType {
 	diType++
 	return DiType(diType)
 }
 
-func (d *Definition) get(values ...reflect.Value) reflect.Value {
-	if val, ok := cache[d.Name]; ok {
-		return val
-	}
+func (c *Container) build(d *Definition, values ...reflect.Value) reflect.Value {
 	fv := reflect.ValueOf(d.Builder)
+	start := time.Now()
 	result := fv.Call(values)
-	val := result[0]
-	cache[d.Name] = val
-	return val
+	elapsed := time.Since(start)
+	c.mu.Lock()
+	d.built = true
+	d.builtAt = start
+	d.buildTime = elapsed
+	c.mu.Unlock()
+	return result[0]
+}
+
+// resolve 依存関係を解決して`d`のモジュールを取り出す
+//
+// Singletonは並行して取り出された場合も一度だけ生成します。
+func (c *Container) resolve(d *Definition) reflect.Value {
+	if d.Lifetime != Singleton {
+		return c.build(d, c.dependencies(d)...)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.value.IsValid() {
+		d.value = c.build(d, c.dependencies(d)...)
+	}
+	return d.value
+}
+
+func (c *Container) dependencies(d *Definition) []reflect.Value {
+	values := []reflect.Value{}
+	for _, name := range d.DiName {
+		values = append(values, c.get(name))
+	}
+	return values
 }
 
 // New DI Containerコンストラクタ
@@ -70,13 +135,7 @@ func (c *Container) Set(d *Definition) interface{} {
 // Get DI Containerからモジュールを取り出す
 func (c *Container) get(key interface{}) reflect.Value {
 	if d, ok := c.definitions[key]; ok {
-		values := []reflect.Value{}
-		for _, name := range d.DiName {
-			val := c.get(name)
-			values = append(values, val)
-		}
-		result := d.get(values...)
-		return result
+		return c.resolve(d)
 	}
 	return reflect.Value{}
 }
@@ -86,16 +145,12 @@ func (c *Container) get(key interface{}) reflect.Value {
 // この時に依存関係は全て解決される。
 func (c *Container) Get(key interface{}) interface{} {
 	if d, ok := c.definitions[key]; ok {
-		values := []reflect.Value{}
 		for _, name := range d.DiName {
 			if key == name {
 				panic(errors.New("自身の名前が依存関係に設定されています。"))
 			}
-			val := c.get(name)
-			values = append(values, val)
 		}
-		result := d.get(values...)
-		return result.Interface()
+		return c.resolve(d).Interface()
 	}
 	return nil
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/belldata-dx/bdx/di"
 	"github.com/belldata-dx/bdx/infra"
 	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
 	"github.com/go-playground/assert/v2"
 	_ "github.com/lib/pq"
 )
@@ -92,8 +93,7 @@ func (h *studentParentHandler) Get(c interfaces.Context) {
 func NewParentInfra(db *infra.DB) ParentRepo {
 	return &parentInfra{
 		RepositoryImple: infra.RepositoryImple{
-			Master: db.Master,
-			Slave:  db.Slave,
+			DB: db,
 		},
 	}
 }
@@ -124,6 +124,7 @@ func (u *parentUseCaseImpl) Find() (result []*ParentModel) {
 }
 
 func TestMultiDI(t *testing.T) {
+	sqlitetest.Skip(t)
 	container := di.New()
 	singleContainer(container)
 	parentInf := di.Increment()
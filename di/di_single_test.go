@@ -10,6 +10,7 @@ import (
 	"github.com/belldata-dx/bdx/di"
 	"github.com/belldata-dx/bdx/infra"
 	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
 	"github.com/go-playground/assert/v2"
 	_ "github.com/lib/pq"
 )
@@ -49,8 +50,7 @@ var (
 func NewStudentInfra(db *infra.DB) StudentRepo {
 	return &studentInfra{
 		RepositoryImple: infra.RepositoryImple{
-			Master: db.Master,
-			Slave:  db.Slave,
+			DB: db,
 		},
 	}
 }
@@ -118,6 +118,7 @@ func singleContainer(container *di.Container) {
 }
 
 func TestSingle(t *testing.T) {
+	sqlitetest.Skip(t)
 	students = []StudentModel{}
 	container := di.New()
 	singleContainer(container)
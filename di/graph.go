@@ -0,0 +1,164 @@
+package di
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/belldata-dx/bdx/interfaces"
+)
+
+type (
+	// Node 依存関係グラフのノード(登録されたモジュール)
+	Node struct {
+		ID        string        `json:"id"`
+		Builder   string        `json:"builder,omitempty"`
+		Type      string        `json:"type,omitempty"`
+		Lifetime  string        `json:"lifetime,omitempty"`
+		Built     bool          `json:"built"`
+		BuiltAt   *time.Time    `json:"built_at,omitempty"`
+		BuildTime time.Duration `json:"build_time_ns"`
+		// Missing 依存先として参照されているがコンテナに登録されていない
+		Missing bool `json:"missing,omitempty"`
+	}
+
+	// Edge `From`が`To`に依存していることを表す
+	Edge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	// Graph DI Containerの依存関係グラフ
+	Graph struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}
+)
+
+func nodeID(name interface{}) string {
+	return fmt.Sprint(name)
+}
+
+func builderName(builder interface{}) string {
+	fv := reflect.ValueOf(builder)
+	if fv.Kind() != reflect.Func {
+		return ""
+	}
+	if f := runtime.FuncForPC(fv.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+func builderType(builder interface{}) string {
+	ft := reflect.TypeOf(builder)
+	if ft == nil || ft.Kind() != reflect.Func || ft.NumOut() == 0 {
+		return ""
+	}
+	return ft.Out(0).String()
+}
+
+// Graph 登録されているモジュールと依存関係をグラフとして返します。
+//
+// ノードとエッジはIDの昇順に並びます。
+func (c *Container) Graph() *Graph {
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	missing := map[string]bool{}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, d := range c.definitions {
+		n := Node{
+			ID:        nodeID(d.Name),
+			Builder:   builderName(d.Builder),
+			Type:      builderType(d.Builder),
+			Lifetime:  d.Lifetime.String(),
+			Built:     d.built,
+			BuildTime: d.buildTime,
+		}
+		if d.built {
+			builtAt := d.builtAt
+			n.BuiltAt = &builtAt
+		}
+		g.Nodes = append(g.Nodes, n)
+		for _, name := range d.DiName {
+			g.Edges = append(g.Edges, Edge{From: n.ID, To: nodeID(name)})
+			if _, ok := c.definitions[name]; !ok {
+				missing[nodeID(name)] = true
+			}
+		}
+	}
+	for id := range missing {
+		g.Nodes = append(g.Nodes, Node{ID: id, Missing: true})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// WriteDOT Graphviz DOT形式で書き込みます
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := strings.Builder{}
+	b.WriteString("digraph di {\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		label := n.ID
+		if n.Builder != "" {
+			label += "\n" + n.Builder
+		}
+		if n.Lifetime != "" {
+			label += "\n" + n.Lifetime
+		}
+		attr := ""
+		if n.Missing {
+			attr = ", color=red, style=dashed"
+		} else if n.Built {
+			attr = ", style=bold"
+		}
+		fmt.Fprintf(&b, "\t%s [label=%s%s];\n", strconv.Quote(n.ID), strconv.Quote(label), attr)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON JSON形式で書き込みます
+func (g *Graph) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(g)
+}
+
+// DebugHandler 登録されているモジュール、生存期間、生成済みかどうか、生成にかかった時間を返すハンドラ
+//
+// `?format=dot`を指定した場合はGraphviz DOT形式、それ以外はJSON形式で返します。
+//     router.GET("/debug/di", container.DebugHandler())
+func (c *Container) DebugHandler() interfaces.BdxHandlerFunc {
+	return func(ctx interfaces.Context) {
+		g := c.Graph()
+		if ctx.Query("format") == "dot" {
+			w := ctx.Response()
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			if err := g.WriteDOT(w); err != nil {
+				ctx.Logger().Debugf("DI依存関係グラフの書き込みエラー: %v", err)
+			}
+			return
+		}
+		ctx.JSON(http.StatusOK, g)
+	}
+}
@@ -0,0 +1,132 @@
+package di_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/belldata-dx/bdx"
+	"github.com/belldata-dx/bdx/di"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	graphRepo    struct{}
+	graphUseCase struct{ repo *graphRepo }
+)
+
+func newGraphRepo() *graphRepo {
+	return &graphRepo{}
+}
+
+func newGraphUseCase(repo *graphRepo) *graphUseCase {
+	return &graphUseCase{repo}
+}
+
+func graphContainer() (container *di.Container, repo, use interface{}) {
+	container = di.New()
+	repo = container.Set(&di.Definition{
+		Builder: newGraphRepo,
+	})
+	use = container.Set(&di.Definition{
+		DiName:   []interface{}{repo},
+		Builder:  newGraphUseCase,
+		Lifetime: di.Transient,
+	})
+	return
+}
+
+func findNode(g *di.Graph, id string) *di.Node {
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == id {
+			return &g.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func TestGraph(t *testing.T) {
+	container, repo, use := graphContainer()
+	g := container.Graph()
+
+	assert.NotNil(t, findNode(g, "DB"))
+
+	useNode := findNode(g, use.(di.DiType).String())
+	assert.NotNil(t, useNode)
+	assert.Equal(t, "transient", useNode.Lifetime)
+	assert.Equal(t, "*di_test.graphUseCase", useNode.Type)
+	assert.True(t, strings.HasSuffix(useNode.Builder, "newGraphUseCase"))
+	assert.False(t, useNode.Built)
+	assert.Contains(t, g.Edges, di.Edge{From: use.(di.DiType).String(), To: repo.(di.DiType).String()})
+
+	u1 := container.Get(use).(*graphUseCase)
+	u2 := container.Get(use).(*graphUseCase)
+	assert.False(t, u1 == u2)
+	assert.True(t, u1.repo == u2.repo)
+
+	g = container.Graph()
+	assert.True(t, findNode(g, use.(di.DiType).String()).Built)
+	assert.True(t, findNode(g, repo.(di.DiType).String()).Built)
+	assert.NotNil(t, findNode(g, repo.(di.DiType).String()).BuiltAt)
+}
+
+func TestGraphConcurrentGet(t *testing.T) {
+	container, _, use := graphContainer()
+	container.Get(use)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			container.Get(use)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		assert.True(t, findNode(container.Graph(), use.(di.DiType).String()).Built)
+	}
+	wg.Wait()
+}
+
+func TestGraphMissing(t *testing.T) {
+	container := di.New()
+	container.Set(&di.Definition{
+		Name:    "handler",
+		DiName:  []interface{}{"usecase"},
+		Builder: newGraphRepo,
+	})
+	g := container.Graph()
+	n := findNode(g, "usecase")
+	assert.NotNil(t, n)
+	assert.True(t, n.Missing)
+}
+
+func TestGraphDOT(t *testing.T) {
+	container, repo, use := graphContainer()
+	buf := bytes.Buffer{}
+	err := container.Graph().WriteDOT(&buf)
+	assert.Nil(t, err)
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph di {"))
+	assert.Contains(t, dot, `"`+use.(di.DiType).String()+`" -> "`+repo.(di.DiType).String()+`";`)
+}
+
+func TestDebugHandler(t *testing.T) {
+	container, _, use := graphContainer()
+	router := bdx.New()
+	router.GET("/debug/di", container.DebugHandler())
+
+	w := request(router, http.MethodGet, "/debug/di", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	g := di.Graph{}
+	err := json.Unmarshal(w.Body.Bytes(), &g)
+	assert.Nil(t, err)
+	assert.NotNil(t, findNode(&g, use.(di.DiType).String()))
+
+	w = request(router, http.MethodGet, "/debug/di?format=dot", "")
+	assert.Equal(t, "text/vnd.graphviz; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "digraph di {")
+}
@@ -0,0 +1,128 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/belldata-dx/bdx/interfaces"
+)
+
+const injectTag = "inject"
+
+var contextType = reflect.TypeOf((*interfaces.Context)(nil)).Elem()
+
+// lookupName タグに指定された名前からモジュール定義を探す
+//
+// 文字列で登録された名前の他に`DB`のような`DiType`の表記とも一致します。
+func (c *Container) lookupName(name string) (*Definition, error) {
+	if d, ok := c.definitions[name]; ok {
+		return d, nil
+	}
+	for key, d := range c.definitions {
+		if nodeID(key) == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("名前 %s のモジュールが登録されていません。", name)
+}
+
+// lookupType 型からモジュール定義を探す
+//
+// Builderの戻り値が`t`へ代入できるモジュールが一つだけ登録されている必要があります。
+func (c *Container) lookupType(t reflect.Type) (*Definition, error) {
+	var found *Definition
+	for _, d := range c.definitions {
+		ft := reflect.TypeOf(d.Builder)
+		if ft == nil || ft.Kind() != reflect.Func || ft.NumOut() == 0 {
+			continue
+		}
+		if !ft.Out(0).AssignableTo(t) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("型 %s に一致するモジュールが複数登録されています。", t)
+		}
+		found = d
+	}
+	if found == nil {
+		return nil, fmt.Errorf("型 %s に一致するモジュールが登録されていません。", t)
+	}
+	return found, nil
+}
+
+// Populate 構造体のフィールドへDI Containerからモジュールを注入する
+//
+// `inject:"name"`タグが設定されたフィールドは名前で、`inject:""`タグのフィールドは型で解決されます。
+// タグが無いフィールドは変更しません。
+//     type studentHandler struct {
+//         Repo StudentRepo     `inject:"student_repo"`
+//         U    IStudentUseCase `inject:""`
+//     }
+//     h := &studentHandler{}
+//     err := container.Populate(h)
+func (c *Container) Populate(target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("構造体のポインタを指定してください。")
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup(injectTag)
+		if !ok {
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("フィールド %s は公開されていないため注入できません。", field.Name)
+		}
+		var d *Definition
+		var err error
+		if name != "" {
+			d, err = c.lookupName(name)
+		} else {
+			d, err = c.lookupType(field.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("フィールド %s: %w", field.Name, err)
+		}
+		val := c.get(d.Name)
+		if !val.IsValid() || !val.Type().AssignableTo(field.Type) {
+			return fmt.Errorf("フィールド %s: モジュール %s は型 %s へ代入できません。", field.Name, nodeID(d.Name), field.Type)
+		}
+		v.Field(i).Set(val)
+	}
+	return nil
+}
+
+// Handler 1番目の引数が`interfaces.Context`の関数を`BdxHandlerFunc`へ変換する
+//
+// 2番目以降の引数はリクエストの度にDI Containerから型で解決されます。
+// 解決できない引数がある場合は登録時にpanicします。
+//     router.GET("/student", container.Handler(func(c interfaces.Context, u IStudentUseCase) {
+//         c.JSON(200, u.Find())
+//     }))
+func (c *Container) Handler(fn interface{}) interfaces.BdxHandlerFunc {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() == 0 || ft.In(0) != contextType {
+		panic(errors.New("1番目の引数が`interfaces.Context`の関数を指定してください。"))
+	}
+	defs := make([]*Definition, 0, ft.NumIn()-1)
+	for i := 1; i < ft.NumIn(); i++ {
+		d, err := c.lookupType(ft.In(i))
+		if err != nil {
+			panic(err)
+		}
+		defs = append(defs, d)
+	}
+	return func(ctx interfaces.Context) {
+		args := make([]reflect.Value, 0, ft.NumIn())
+		args = append(args, reflect.ValueOf(ctx))
+		for _, d := range defs {
+			args = append(args, c.get(d.Name))
+		}
+		fv.Call(args)
+	}
+}
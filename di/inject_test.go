@@ -0,0 +1,133 @@
+package di_test
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx"
+	"github.com/belldata-dx/bdx/di"
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	injectRepo interface {
+		Name() string
+	}
+	injectRepoImpl struct{}
+	injectUseCase  struct {
+		repo injectRepo
+	}
+	injectTarget struct {
+		Repo    injectRepo     `inject:"inject_repo"`
+		UseCase *injectUseCase `inject:""`
+		Other   string
+	}
+)
+
+func (*injectRepoImpl) Name() string {
+	return "repo"
+}
+
+func newInjectRepo() injectRepo {
+	return &injectRepoImpl{}
+}
+
+func newInjectUseCase(repo injectRepo) *injectUseCase {
+	return &injectUseCase{repo}
+}
+
+func injectContainer() *di.Container {
+	container := di.New()
+	container.Set(&di.Definition{
+		Name:    "inject_repo",
+		Builder: newInjectRepo,
+	})
+	container.Set(&di.Definition{
+		DiName:   []interface{}{"inject_repo"},
+		Builder:  newInjectUseCase,
+		Lifetime: di.Transient,
+	})
+	return container
+}
+
+func TestPopulate(t *testing.T) {
+	container := injectContainer()
+	target := &injectTarget{Other: "other"}
+	err := container.Populate(target)
+	assert.Nil(t, err)
+	assert.Equal(t, "repo", target.Repo.Name())
+	assert.NotNil(t, target.UseCase)
+	assert.True(t, target.Repo == target.UseCase.repo)
+	assert.Equal(t, "other", target.Other)
+}
+
+func TestPopulateError(t *testing.T) {
+	container := injectContainer()
+	assert.NotNil(t, container.Populate(injectTarget{}))
+	assert.NotNil(t, container.Populate(&struct {
+		Repo injectRepo `inject:"unknown"`
+	}{}))
+	assert.NotNil(t, container.Populate(&struct {
+		repo injectRepo `inject:""`
+	}{}))
+	assert.NotNil(t, container.Populate(&struct {
+		Any interface{} `inject:""`
+	}{}))
+}
+
+func TestInjectHandler(t *testing.T) {
+	container := injectContainer()
+	router := bdx.New()
+	var prev *injectUseCase
+	count := 0
+	router.GET("/inject", container.Handler(func(c interfaces.Context, u *injectUseCase, repo injectRepo) {
+		assert.True(t, u != prev)
+		assert.True(t, u.repo == repo)
+		prev = u
+		count++
+		c.JSON(http.StatusOK, bdx.B{"name": repo.Name()})
+	}))
+	request(router, http.MethodGet, "/inject", "")
+	w := request(router, http.MethodGet, "/inject", "")
+	assert.Equal(t, 2, count)
+	assert.Equal(t, `{"name":"repo"}`, w.Body.String())
+
+	assert.Panics(t, func() {
+		container.Handler(func(c interfaces.Context, s string) {})
+	})
+	assert.Panics(t, func() {
+		container.Handler(func(u *injectUseCase) {})
+	})
+}
+
+func TestInjectHandlerConcurrent(t *testing.T) {
+	var built int32
+	container := di.New()
+	container.Set(&di.Definition{
+		Builder: func() injectRepo {
+			atomic.AddInt32(&built, 1)
+			time.Sleep(10 * time.Millisecond)
+			return &injectRepoImpl{}
+		},
+	})
+	router := bdx.New()
+	router.GET("/inject", container.Handler(func(c interfaces.Context, repo injectRepo) {
+		c.JSON(http.StatusOK, bdx.B{"name": repo.Name()})
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request(router, http.MethodGet, "/inject", "")
+		}()
+	}
+	wg.Wait()
+	// Singletonは並行したリクエストでも一度だけ生成される
+	assert.Equal(t, int32(1), atomic.LoadInt32(&built))
+}
@@ -0,0 +1,14 @@
+package di_test
+
+import (
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMain(m *testing.M) {
+	// `di.DB`はインメモリのSQLiteへ接続する
+	os.Setenv("DB_DIALECT", "sqlite3")
+	os.Exit(m.Run())
+}
@@ -11,7 +11,10 @@ require (
 	github.com/jinzhu/gorm v1.9.15
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/lib/pq v1.8.0
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/newrelic/go-agent v3.8.1+incompatible
 	github.com/stretchr/testify v1.4.0
+	github.com/ugorji/go/codec v1.1.7
+	google.golang.org/protobuf v1.27.1
 	gopkg.in/yaml.v2 v2.3.0
 )
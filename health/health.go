@@ -0,0 +1,215 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/belldata-dx/bdx/interfaces"
+)
+
+const (
+	// StatusUp 正常
+	StatusUp = "up"
+	// StatusDown 異常
+	StatusDown = "down"
+
+	// DefaultTimeout `Check.Timeout`が未指定の場合のタイムアウト
+	DefaultTimeout = 3 * time.Second
+	// DefaultCacheTTL `Health.CacheTTL`が未指定の場合の結果のキャッシュ期間
+	DefaultCacheTTL = time.Second
+)
+
+// ErrShuttingDown 停止処理中
+var ErrShuttingDown = errors.New("停止処理中です。")
+
+type (
+	// Check ヘルスチェック
+	Check struct {
+		// Name チェック名
+		Name string
+		// Func チェック処理(異常の場合はエラーを返します)
+		Func func(ctx context.Context) error
+		// Timeout チェック処理のタイムアウト(未指定の場合は`DefaultTimeout`)
+		Timeout time.Duration
+		// CacheTTL 結果をキャッシュする期間(未指定の場合は`Health.CacheTTL`、負の値の場合はキャッシュしない)
+		CacheTTL time.Duration
+		// Optional 失敗しても全体のステータスを`down`にしない
+		Optional bool
+		// Liveness Livenessチェックにも含める
+		Liveness bool
+
+		mu     sync.Mutex
+		last   Result
+		expire time.Time
+	}
+
+	// Result チェック1つ分の結果
+	Result struct {
+		Name      string        `json:"name"`
+		Status    string        `json:"status"`
+		Error     string        `json:"error,omitempty"`
+		Optional  bool          `json:"optional,omitempty"`
+		Duration  time.Duration `json:"duration_ns"`
+		CheckedAt time.Time     `json:"checked_at"`
+	}
+
+	// Report チェック結果のレポート
+	Report struct {
+		Status string   `json:"status"`
+		Checks []Result `json:"checks"`
+	}
+
+	// Health ヘルスチェックの登録先
+	Health struct {
+		// CacheTTL 結果をキャッシュする期間のデフォルト(未指定の場合は`DefaultCacheTTL`)
+		CacheTTL time.Duration
+
+		mu       sync.RWMutex
+		checks   []*Check
+		shutdown int32
+	}
+)
+
+// New Healthを生成します。
+//     h := health.New()
+//     h.Register(db.HealthChecks()...)
+//     router.GET("/livez", h.LivezHandler())
+//     router.GET("/readyz", h.ReadyzHandler())
+//     router.OnShutdown(h.Shutdown)
+func New() *Health {
+	return &Health{}
+}
+
+// Register ヘルスチェックを登録します。
+func (h *Health) Register(checks ...*Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, checks...)
+}
+
+// Shutdown 停止処理中として以降のReadinessチェックを失敗させます。
+func (h *Health) Shutdown() {
+	atomic.StoreInt32(&h.shutdown, 1)
+}
+
+// ShuttingDown 停止処理中かどうか
+func (h *Health) ShuttingDown() bool {
+	return atomic.LoadInt32(&h.shutdown) == 1
+}
+
+func (h *Health) cacheTTL(c *Check) time.Duration {
+	if c.CacheTTL != 0 {
+		return c.CacheTTL
+	}
+	if h.CacheTTL != 0 {
+		return h.CacheTTL
+	}
+	return DefaultCacheTTL
+}
+
+// run チェック処理を実行します。キャッシュ期間内の場合は前回の結果を返します。
+func (c *Check) run(ctx context.Context, ttl time.Duration) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if ttl > 0 && now.Before(c.expire) {
+		return c.last
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Func(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{Name: c.Name, Status: StatusUp, Optional: c.Optional, Duration: time.Since(now), CheckedAt: now}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.last = result
+	c.expire = now.Add(ttl)
+	return result
+}
+
+func (h *Health) report(ctx context.Context, liveness bool) Report {
+	h.mu.RLock()
+	checks := make([]*Check, 0, len(h.checks))
+	for _, c := range h.checks {
+		if !liveness || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, h.cacheTTL(c))
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, r := range results {
+		if r.Status == StatusDown && !r.Optional {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// Liveness Livenessチェック(`Liveness`が指定されたチェックのみ)を実行します。
+func (h *Health) Liveness(ctx context.Context) Report {
+	return h.report(ctx, true)
+}
+
+// Readiness 全てのチェックを実行します。停止処理中の場合は常に`down`になります。
+func (h *Health) Readiness(ctx context.Context) Report {
+	report := h.report(ctx, false)
+	if h.ShuttingDown() {
+		report.Status = StatusDown
+		report.Checks = append(report.Checks, Result{Name: "shutdown", Status: StatusDown, Error: ErrShuttingDown.Error(), CheckedAt: time.Now()})
+	}
+	return report
+}
+
+func handler(check func(ctx context.Context) Report) interfaces.BdxHandlerFunc {
+	return func(c interfaces.Context) {
+		report := check(c.Request().Context())
+		code := http.StatusOK
+		if report.Status != StatusUp {
+			code = http.StatusServiceUnavailable
+		}
+		c.Response().Header().Set("Cache-Control", "no-store")
+		c.JSON(code, report)
+	}
+}
+
+// LivezHandler Livenessチェックの結果をJSONで返すハンドラ(異常の場合は503)
+func (h *Health) LivezHandler() interfaces.BdxHandlerFunc {
+	return handler(h.Liveness)
+}
+
+// ReadyzHandler Readinessチェックの結果をJSONで返すハンドラ(異常の場合は503)
+func (h *Health) ReadyzHandler() interfaces.BdxHandlerFunc {
+	return handler(h.Readiness)
+}
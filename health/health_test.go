@@ -0,0 +1,87 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx"
+	"github.com/belldata-dx/bdx/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	var calls int32
+	var failed atomic.Value
+	failed.Store(false)
+	h := health.New()
+	h.Register(&health.Check{
+		Name: "custom",
+		Func: func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			if failed.Load().(bool) {
+				return errors.New("failed")
+			}
+			return nil
+		},
+		CacheTTL: time.Hour,
+	}, &health.Check{
+		Name: "slow",
+		Func: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Timeout:  10 * time.Millisecond,
+		Optional: true,
+		Liveness: true,
+	})
+
+	router := bdx.New()
+	router.GET("/livez", h.LivezHandler())
+	router.GET("/readyz", h.ReadyzHandler())
+	get := func(path string) (int, health.Report) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report health.Report
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, report
+	}
+
+	code, report := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Equal(t, "custom", report.Checks[0].Name)
+	assert.Equal(t, health.StatusDown, report.Checks[1].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[1].Error)
+
+	failed.Store(true)
+	code, _ = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	code, report = get("/livez")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, report.Checks, 1)
+
+	h.Shutdown()
+	code, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutdown", report.Checks[len(report.Checks)-1].Name)
+	code, _ = get("/livez")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealthFailure(t *testing.T) {
+	h := health.New()
+	h.Register(&health.Check{Name: "db", Func: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}})
+	report := h.Readiness(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+}
@@ -0,0 +1,147 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const defaultQueryTimeout = 30 * time.Second
+
+// ctxConn 全ての操作に`context.Context`を渡す`gorm.SQLCommon`
+//
+// `Exec`、`Prepare`には`timeout`を適用します。
+// `Query`、`QueryRow`、`Begin`の結果は呼び出し元が後から読み込むため、タイムアウトまでキャンセルしません。
+type ctxConn struct {
+	ctx     context.Context
+	conn    *sql.DB
+	timeout time.Duration
+	log     *sqlLogger
+}
+
+var _ gorm.SQLCommon = &ctxConn{}
+
+// withTimeout `timeout`を適用したコンテキストを返します。
+func (c *ctxConn) withTimeout() (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, c.timeout)
+}
+
+// deadline `timeout`を適用し、タイムアウトかリクエストの終了まで有効なコンテキストを返します。
+func (c *ctxConn) deadline() context.Context {
+	if c.timeout <= 0 {
+		return c.ctx
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	// 結果を読み終える時点は分からないため、タイムアウトした時点で解放する
+	time.AfterFunc(c.timeout, cancel)
+	return ctx
+}
+
+// logCancel キャンセルとタイムアウトを他のエラーと区別してログ出力します。
+func (c *ctxConn) logCancel(err error, query string) {
+	switch {
+	case err == nil:
+		return
+	case errors.Is(err, context.Canceled) || errors.Is(c.ctx.Err(), context.Canceled):
+		c.log.log.Infof("%s リクエストがキャンセルされたためクエリを中断しました: %s", c.log.prefix(), query)
+	case errors.Is(err, context.DeadlineExceeded):
+		c.log.log.Warnf("%s クエリがタイムアウトしました: %s", c.log.prefix(), query)
+	default:
+		return
+	}
+	c.log.logged(err)
+}
+
+// Exec `ExecContext`を実行します。
+func (c *ctxConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := c.withTimeout()
+	defer cancel()
+	result, err := c.conn.ExecContext(ctx, query, args...)
+	c.logCancel(err, query)
+	return result, err
+}
+
+// Prepare `PrepareContext`を実行します。
+func (c *ctxConn) Prepare(query string) (*sql.Stmt, error) {
+	ctx, cancel := c.withTimeout()
+	defer cancel()
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	c.logCancel(err, query)
+	return stmt, err
+}
+
+// Query `QueryContext`を実行します。
+func (c *ctxConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := c.conn.QueryContext(c.deadline(), query, args...)
+	c.logCancel(err, query)
+	return rows, err
+}
+
+// QueryRow `QueryRowContext`を実行します。
+// エラーは`Scan`まで返されないため、コンテキストが終了している場合のみログ出力します。
+func (c *ctxConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	row := c.conn.QueryRowContext(c.deadline(), query, args...)
+	c.logCancel(c.ctx.Err(), query)
+	return row
+}
+
+// Begin `ctx`に紐付いたトランザクションを開始します。
+// gormが`Create`、`Update`、`Delete`の度に開始するトランザクションには`timeout`を適用します。
+func (c *ctxConn) Begin() (*sql.Tx, error) {
+	tx, err := c.conn.BeginTx(c.deadline(), nil)
+	c.logCancel(err, "BEGIN")
+	return tx, err
+}
+
+// BeginTx トランザクションを開始します。
+func (c *ctxConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := c.conn.BeginTx(ctx, opts)
+	c.logCancel(err, "BEGIN")
+	return tx, err
+}
+
+// bind `n`への操作を`ctx`に紐付けたハンドルを返します。
+//
+// `n`と接続を共有する別のハンドルを作成します。
+// `DB.SingularTable`と`BlockGlobalUpdate`の設定は引き継ぎますが、`n`のハンドルに追加したコールバックは引き継ぎません。
+func (db *DB) bind(ctx context.Context, n *node) *gorm.DB {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	log := n.log
+	if log == nil {
+		log = &sqlLogger{log: db.log, node: n.name}
+	}
+	log = log.bound(ctx)
+	conn := &ctxConn{ctx: ctx, conn: n.db.DB(), timeout: db.queryTimeout, log: log}
+	// `*sql.DB`以外の接続を渡した場合は疎通確認をしないため、エラーは返らない
+	bound, _ := gorm.Open(n.db.Dialect().GetName(), conn)
+	bound.SetLogger(log)
+	bound.LogMode(log.active())
+	bound.SingularTable(db.singularTable)
+	bound.BlockGlobalUpdate(n.db.HasBlockGlobalUpdate())
+	return bound
+}
+
+// WriterContext `ctx`に紐付いたReadWrite Node
+//
+// 各クエリには`ctx`と`Config.QueryTimeout`が適用され、リクエストがキャンセルされた場合は実行中のクエリも中断します。
+// 接続は`*sql.DB`ではないため、`DB()`の代わりに`Writer().DB()`を使用してください。
+//     db.WriterContext(c.Request().Context()).Create(&student)
+func (db *DB) WriterContext(ctx context.Context) *gorm.DB {
+	return db.bind(ctx, db.primary)
+}
+
+// ReaderContext `ctx`に紐付いたReadOnly Node
+//
+// Nodeの選択は`Reader()`と同じです。
+//     db.ReaderContext(c.Request().Context()).Find(&students)
+func (db *DB) ReaderContext(ctx context.Context) *gorm.DB {
+	return db.bind(ctx, db.reader())
+}
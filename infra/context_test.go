@@ -0,0 +1,76 @@
+package infra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterContext(t *testing.T) {
+	rec := &recordLogger{}
+	sqlitetest.Skip(t)
+	db, err := Open(&Config{Dialect: SQLite, Logger: rec})
+	assert.Nil(t, err)
+	defer db.Close()
+	repo := &studentInfra{RepositoryImple{DB: db}}
+	assert.Nil(t, db.WriterContext(context.Background()).AutoMigrate(&studentModel{}).Error)
+	assert.Nil(t, repo.Create(context.Background(), &studentModel{Name: "a"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.Find(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, repo.Create(ctx, &studentModel{Name: "b"}))
+	assert.Len(t, rec.lines, 2)
+	assert.Regexp(t, `^INFO \[primary\] リクエストがキャンセルされたためクエリを中断しました: SELECT`, rec.lines[0])
+	assert.Equal(t, "INFO [primary] リクエストがキャンセルされたためクエリを中断しました: BEGIN", rec.lines[1])
+
+	rec.lines = nil
+	db.queryTimeout = time.Nanosecond
+	_, err = repo.Find(context.Background())
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, []string{`WARN [primary] クエリがタイムアウトしました: SELECT * FROM "student_models"   ORDER BY "id"`}, rec.lines)
+
+	db.queryTimeout = -1
+	students, err := repo.Find(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, students, 1)
+}
+
+func TestWriterContextKeepsSettings(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	db.SingularTable(true)
+	db.Writer().BlockGlobalUpdate(true)
+
+	conn := db.WriterContext(context.Background())
+	assert.Equal(t, db.Writer().DB(), conn.CommonDB().(*ctxConn).conn)
+	assert.Nil(t, conn.AutoMigrate(&studentModel{}).Error)
+	assert.True(t, db.Writer().HasTable("student_model"))
+	assert.Nil(t, conn.Create(&studentModel{Name: "a"}).Error)
+	assert.EqualError(t, db.WriterContext(context.Background()).Delete(&studentModel{}).Error, "missing WHERE clause while deleting")
+}
+
+func TestWriterContextRows(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	assert.Nil(t, db.Writer().AutoMigrate(&studentModel{}).Error)
+	assert.Nil(t, db.Writer().Create(&studentModel{Name: "a"}).Error)
+
+	// `Rows`の結果はクエリを実行した後に読み込む
+	rows, err := db.WriterContext(context.Background()).Model(&studentModel{}).Select("name").Rows()
+	assert.Nil(t, err)
+	defer rows.Close()
+	assert.True(t, rows.Next())
+	var name string
+	assert.Nil(t, rows.Scan(&name))
+	assert.Equal(t, "a", name)
+
+	// 紐付けていないハンドルとgormのデフォルトのコールバックは変更しない
+	assert.Equal(t, db.Writer().DB(), db.Writer().CommonDB())
+	assert.Nil(t, gorm.DefaultCallback.Query().Get("infra:bind_context"))
+}
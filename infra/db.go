@@ -2,100 +2,202 @@ package infra
 
 import (
 	"fmt"
-	"os"
-	"strings"
+	"sync"
+	"time"
 
+	logger "github.com/belldata-dx/bdx-logger"
 	"github.com/jinzhu/gorm"
 )
 
 // DB ReadWrite/ReadOnly オブジェクト
+//
+// ReadWrite Nodeは`Writer()`、ReadOnly Nodeは`Reader()`で取得します。
 type DB struct {
-	// Master ReadWrite Node
-	Master *gorm.DB
-	// Slave ReadOnly Node
-	Slave *gorm.DB
-}
+	primary  *node
+	replicas []*node
+	policy   ReplicaPolicy
+	dialect  Dialect
+	schema   string
+	log      logger.ILogger
+	retry    RetryConfig
+	counter  uint64
 
-var (
-	db *DB
-)
+	// lazyRetry ヘルスチェックが無効なため`Reader()`の呼び出し時に再接続を試行するか
+	lazyRetry bool
+	// healthTimeout ヘルスチェック1回あたりのタイムアウト
+	healthTimeout time.Duration
 
-func getEnv(key, defVal string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
-	}
+	// queryTimeout `WriterContext`、`ReaderContext`で適用するクエリ1回あたりのタイムアウト
+	queryTimeout time.Duration
+	// singularTable `WriterContext`、`ReaderContext`のハンドルに引き継ぐ`SingularTable`の設定
+	singularTable bool
 
-	return defVal
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
 }
 
-func dataSourceKey(key, val string) string {
-	return fmt.Sprintf("%s=%s ", key, val)
-}
-
-func dataSource(host, user, dbName, sslMode, passWord, port, schemaName string) string {
-	dns := strings.Builder{}
-	dns.WriteString(host)
-	dns.WriteString(user)
-	dns.WriteString(dbName)
-	dns.WriteString(sslMode)
-	dns.WriteString(passWord)
-	dns.WriteString(port)
-	if schemaName != "" {
-		schemaName = dataSourceKey("search_path", schemaName)
-		dns.WriteString(schemaName)
+// Open 接続設定からDBへ接続します。
+//
+// `Retry.Timeout`が指定されている場合は疎通できるまで再試行します。
+// 疎通できないReadOnly Nodeは切り離した状態で開始し、ヘルスチェックで再接続します。
+// ヘルスチェックが無効な場合は`Reader()`の呼び出し時に再接続を試行します。
+//
+// ReadOnly Nodeが設定されている場合はバックグラウンドでヘルスチェックを開始します。
+// 不要になった場合は`Close()`で接続とヘルスチェックを終了してください。
+func Open(cfg *Config) (*DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	return dns.String()
-}
 
-func dbOpen(dialect, dns string) *gorm.DB {
-	db, err := gorm.Open("postgres", dns)
+	masterDSN, err := cfg.masterDataSource()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	err = db.DB().Ping()
+	dialect := string(cfg.dialect())
+	log := cfg.logger()
+	master, err := cfg.Retry.connect(log, "ReadWrite Node", dialect, masterDSN)
 	if err != nil {
-		panic(err)
+		if master != nil {
+			master.Close()
+		}
+		return nil, fmt.Errorf("ReadWrite Nodeへの接続エラー: %w", err)
 	}
-	return db
+	pool := cfg.Pool
+	if cfg.dialect() == SQLite && isPrivateMemory(masterDSN) && pool.MaxOpenConns == 0 {
+		// インメモリDBは接続毎に別のDBになるため、接続を1つに制限する
+		pool.MaxOpenConns = 1
+	}
+	pool.apply(master)
+	db := newDB(cfg, newNode("primary", master))
+
+	// レプリケーションされたDBの場合
+	for i, r := range cfg.Replicas {
+		name := fmt.Sprintf("replica-%d", i)
+		dsn, err := cfg.replicaDataSource(r)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		slave, err := cfg.Retry.connect(log, fmt.Sprintf("ReadOnly Node(%s)", name), dialect, dsn)
+		if slave == nil {
+			db.Close()
+			return nil, fmt.Errorf("ReadOnly Node(%s)への接続エラー: %w", name, err)
+		}
+		cfg.replicaPool(r).apply(slave)
+		n := newNode(name, slave)
+		if err != nil {
+			// 疎通できないReadOnly Nodeは切り離した状態で開始し、ヘルスチェックで再接続する
+			n.detach(db.retry)
+		}
+		db.replicas = append(db.replicas, n)
+	}
+
+	for _, n := range db.nodes() {
+		l := newSQLLogger(cfg, db.log, n.name)
+		n.log = l
+		n.db.SetLogger(l)
+		n.db.LogMode(l.active())
+	}
+
+	db.startHealthCheck(cfg.healthCheckInterval(), cfg.healthCheckTimeout())
+	db.startMetrics(cfg.metricsInterval(), cfg.MetricsHook)
+	return db, nil
 }
 
-// NewDBInit DB接続
-func NewDBInit() *DB {
-	if db != nil {
-		return db
+func newDB(cfg *Config, primary *node) *DB {
+	policy := cfg.ReplicaPolicy
+	if policy == "" {
+		policy = RoundRobin
 	}
+	return &DB{
+		primary: primary,
+		policy:  policy,
+		dialect: cfg.dialect(),
+		schema:  cfg.Schema,
+		log:     cfg.logger(),
+		retry:   cfg.Retry,
+		stop:    make(chan struct{}),
+
+		queryTimeout: cfg.queryTimeout(),
+	}
+}
 
-	var user, passWord, masterHost, slaveHost, port, dbName, schemaName, replica string
-	var master, slave *gorm.DB
+// Writer ReadWrite Node
+func (db *DB) Writer() *gorm.DB {
+	return db.primary.db
+}
 
-	user = dataSourceKey("user", getEnv("DB_USER", "postgres"))
-	passWord = dataSourceKey("password", getEnv("DB_PASSWORD", "postgres"))
-	masterHost = dataSourceKey("host", getEnv("DB_MASTER_NAME", "host.docker.internal"))
-	port = dataSourceKey("port", getEnv("DB_PORT", "30543"))
-	dbName = dataSourceKey("dbname", getEnv("DB_DBNAME", "postgres"))
-	schemaName = getEnv("DB_SCHEMA", "stockpile")
-	sslMode := dataSourceKey("sslmode", "disable")
-	master = dbOpen("postgres", dataSource(masterHost, user, dbName, sslMode, passWord, port, schemaName))
+// SingularTable 全てのNodeと`WriterContext`、`ReaderContext`のハンドルでテーブル名を単数形にします。
+//
+// リクエストを処理する前に呼び出してください。
+func (db *DB) SingularTable(enable bool) {
+	db.singularTable = enable
+	for _, n := range db.nodes() {
+		n.db.SingularTable(enable)
+	}
+}
 
-	// レプリケーションされたDBの場合
-	replica = getEnv("REPLICA", "N")
-	if replica == "Y" {
-		slaveHost = dataSourceKey("host", getEnv("DB_SLAVE_NAME", "host.docker.internal"))
-		slave = dbOpen("postgres", dataSource(slaveHost, user, dbName, sslMode, passWord, port, schemaName))
+// Reader ReadOnly Node
+//
+// `ReplicaPolicy`に従って正常なReadOnly Nodeを選択します。
+// ReadOnly Nodeが無い、もしくは全て異常な場合はReadWrite Nodeを返します。
+func (db *DB) Reader() *gorm.DB {
+	return db.reader().db
+}
+
+func (db *DB) reader() *node {
+	if n := db.pick(); n != nil {
+		return n
 	}
-	sqlLog := getEnv("DB_LOG", "Y")
-	if sqlLog == "Y" {
-		if master != nil {
-			master.LogMode(true)
-		}
-		if slave != nil {
-			slave.LogMode(true)
+	return db.primary
+}
+
+// Dialect 接続しているDBの種類
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// Schema 接続設定の`Schema`
+func (db *DB) Schema() string {
+	return db.schema
+}
+
+// Logger infraが使用するlogger
+func (db *DB) Logger() logger.ILogger {
+	return db.log
+}
+
+func (db *DB) nodes() []*node {
+	return append([]*node{db.primary}, db.replicas...)
+}
+
+// Close ヘルスチェックを終了し、全てのNodeとの接続を閉じます。
+func (db *DB) Close() error {
+	db.stopOnce.Do(func() {
+		close(db.stop)
+	})
+	db.wg.Wait()
+	var err error
+	for _, n := range db.nodes() {
+		if cErr := n.db.Close(); cErr != nil {
+			err = cErr
 		}
 	}
+	return err
+}
 
-	db = &DB{
-		Master: master,
-		Slave:  slave,
+// NewDBInit 環境変数(`DefaultEnvPrefix`)の接続設定でDB接続
+//
+// 接続できない場合はpanicします。エラーを扱う場合は`Open`を使用してください。
+func NewDBInit() *DB {
+	cfg, err := ConfigFromEnv(DefaultEnvPrefix)
+	if err != nil {
+		panic(err)
+	}
+	db, err := Open(cfg)
+	if err != nil {
+		panic(err)
 	}
 	return db
 }
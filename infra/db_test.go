@@ -1,23 +1,86 @@
 package infra
 
 import (
-	"os"
+	"context"
 	"testing"
 
-	_ "github.com/lib/pq"
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
 	"github.com/stretchr/testify/assert"
 )
 
+type (
+	studentModel struct {
+		ID   int
+		Name string
+	}
+	studentInfra struct {
+		RepositoryImple
+	}
+)
+
+func (s *studentInfra) Create(ctx context.Context, model *studentModel) error {
+	return s.Conn(ctx).Create(model).Error
+}
+
+func (s *studentInfra) Find(ctx context.Context) (result []*studentModel, err error) {
+	err = s.ReadConn(ctx).Order("id").Find(&result).Error
+	return
+}
+
+func openSQLite(t *testing.T) *DB {
+	sqlitetest.Skip(t)
+	db, err := Open(&Config{Dialect: SQLite, LogMode: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
 func TestDB(t *testing.T) {
-	os.Setenv("REPLICA", "Y")
 	var count1 int
 	var count2 int
-	sql := `select count(*) from information_schema."tables"`
-	db := NewDBInit()
-	err := db.Master.Raw(sql).Row().Scan(&count1)
+	sql := `select count(*) from sqlite_master`
+	db := openSQLite(t)
+	defer db.Close()
+	err := db.Writer().Raw(sql).Row().Scan(&count1)
 	if err != nil {
 		t.Error(err.Error())
 	}
-	err = db.Slave.Raw(sql).Row().Scan(&count2)
+	err = db.Reader().Raw(sql).Row().Scan(&count2)
+	assert.Nil(t, err)
 	assert.Equal(t, count1, count2)
 }
+
+func TestRepository(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	assert.Nil(t, db.Writer().AutoMigrate(&studentModel{}).Error)
+	repo := &studentInfra{RepositoryImple{DB: db}}
+	tm := NewTxManager(db)
+	ctx := context.Background()
+
+	assert.Nil(t, repo.Create(ctx, &studentModel{Name: "a"}))
+	err := tm.WithTx(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, &studentModel{Name: "b"}); err != nil {
+			return err
+		}
+		tm.WithTx(ctx, func(ctx context.Context) error {
+			repo.Create(ctx, &studentModel{Name: "c"})
+			return context.Canceled
+		})
+		return nil
+	})
+	assert.Nil(t, err)
+	tm.WithTx(ctx, func(ctx context.Context) error {
+		repo.Create(ctx, &studentModel{Name: "d"})
+		return context.Canceled
+	})
+
+	students, err := repo.Find(ctx)
+	assert.Nil(t, err)
+	names := []string{}
+	for _, s := range students {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"a", "b"}, names)
+}
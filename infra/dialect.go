@@ -0,0 +1,203 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Dialect 接続するDBの種類(gormのdialect名)
+type Dialect string
+
+const (
+	// Postgres PostgreSQL
+	Postgres Dialect = "postgres"
+	// MySQL MySQL
+	//
+	// 複数の文を1回で実行できるように、DSNに`multiStatements=true`を追加します(DSNで指定した値が優先されます)。
+	// ドライバを依存に含めていないため、テストはDSNの生成のみです。
+	MySQL Dialect = "mysql"
+	// SQLite SQLite3
+	//
+	// `github.com/mattn/go-sqlite3`はcgoが必要なため、SQLiteに接続するテストはcgoが有効な場合のみ実行します。
+	SQLite Dialect = "sqlite3"
+)
+
+// dataSourceFunc 接続設定からDialect毎のDSNを生成する関数
+//
+// `dsn`が指定された場合はそれに接続オプションを追加し、それ以外は`host`と接続設定から生成します。
+type dataSourceFunc func(cfg *Config, dsn, host string) (string, error)
+
+var dataSources = map[Dialect]dataSourceFunc{
+	Postgres: postgresDataSource,
+	MySQL:    mysqlDataSource,
+	SQLite:   sqliteDataSource,
+}
+
+func (d Dialect) dataSource(cfg *Config, dsn, host string) (string, error) {
+	f, ok := dataSources[d]
+	if !ok {
+		return "", fmt.Errorf("Dialect %q には対応していません。", d)
+	}
+	return f(cfg, dsn, host)
+}
+
+// postgresOptions DSNへ追加する接続オプション
+func postgresOptions(cfg *Config) map[string]string {
+	opts := map[string]string{}
+	if cfg.Schema != "" {
+		opts["search_path"] = cfg.Schema
+	}
+	if cfg.SSLMode != "" {
+		opts["sslmode"] = cfg.SSLMode
+	}
+	if cfg.SSLRootCert != "" {
+		opts["sslrootcert"] = cfg.SSLRootCert
+	}
+	if cfg.SSLCert != "" {
+		opts["sslcert"] = cfg.SSLCert
+	}
+	if cfg.SSLKey != "" {
+		opts["sslkey"] = cfg.SSLKey
+	}
+	return opts
+}
+
+func parseURLDSN(dsn string) (*url.URL, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("DSNの解析エラー: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("DSNのスキーム %q には対応していません。", u.Scheme)
+	}
+	return u, nil
+}
+
+func postgresDataSource(cfg *Config, dsn, host string) (string, error) {
+	opts := postgresOptions(cfg)
+	if dsn != "" {
+		if strings.Contains(dsn, "://") {
+			u, err := parseURLDSN(dsn)
+			if err != nil {
+				return "", err
+			}
+			q := u.Query()
+			for key, val := range opts {
+				if q.Get(key) == "" {
+					q.Set(key, val)
+				}
+			}
+			u.RawQuery = q.Encode()
+			return u.String(), nil
+		}
+		b := strings.Builder{}
+		b.WriteString(dsn)
+		for _, key := range sortedKeys(opts) {
+			if !strings.Contains(dsn, key+"=") {
+				b.WriteString(" ")
+				b.WriteString(dataSourceKey(key, opts[key]))
+			}
+		}
+		return b.String(), nil
+	}
+
+	opts["host"] = orDefault(host, "localhost")
+	opts["port"] = orDefault(cfg.Port, "5432")
+	opts["user"] = orDefault(cfg.User, "postgres")
+	opts["dbname"] = orDefault(cfg.DBName, "postgres")
+	if cfg.Password != "" {
+		opts["password"] = cfg.Password
+	}
+	kv := make([]string, 0, len(opts))
+	for _, key := range sortedKeys(opts) {
+		kv = append(kv, dataSourceKey(key, opts[key]))
+	}
+	return strings.Join(kv, " "), nil
+}
+
+// dataSourceKey key=value形式の値をエスケープして返します。
+func dataSourceKey(key, val string) string {
+	if val == "" || strings.ContainsAny(val, ` '\`) {
+		val = strings.ReplaceAll(val, `\`, `\\`)
+		val = strings.ReplaceAll(val, `'`, `\'`)
+		val = "'" + val + "'"
+	}
+	return key + "=" + val
+}
+
+// mysqlTLS `SSLMode`に対応する`tls`パラメータ
+var mysqlTLS = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
+func mysqlDataSource(cfg *Config, dsn, host string) (string, error) {
+	if cfg.SSLRootCert != "" || cfg.SSLCert != "" || cfg.SSLKey != "" {
+		return "", errors.New("MySQLでは証明書ファイルの指定に対応していません。DSNの`tls`パラメータを使用してください。")
+	}
+	// マイグレーションファイルのように複数の文を1回で実行できるようにする
+	opts := map[string]string{"parseTime": "true", "multiStatements": "true"}
+	if cfg.SSLMode != "" {
+		opts["tls"] = mysqlTLS[cfg.SSLMode]
+	}
+
+	b := strings.Builder{}
+	if dsn != "" {
+		b.WriteString(dsn)
+	} else {
+		b.WriteString(orDefault(cfg.User, "root"))
+		if cfg.Password != "" {
+			b.WriteString(":")
+			b.WriteString(cfg.Password)
+		}
+		fmt.Fprintf(&b, "@tcp(%s:%s)/%s", orDefault(host, "localhost"), orDefault(cfg.Port, "3306"), cfg.DBName)
+	}
+	sep := "?"
+	if strings.Contains(b.String(), "?") {
+		sep = "&"
+	}
+	for _, key := range sortedKeys(opts) {
+		if !strings.Contains(dsn, key+"=") {
+			b.WriteString(sep)
+			b.WriteString(key + "=" + url.QueryEscape(opts[key]))
+			sep = "&"
+		}
+	}
+	return b.String(), nil
+}
+
+func sqliteDataSource(cfg *Config, dsn, host string) (string, error) {
+	if dsn != "" {
+		return dsn, nil
+	}
+	return orDefault(cfg.DBName, ":memory:"), nil
+}
+
+// isPrivateMemory 接続毎に別のDBになるSQLiteのインメモリDBかどうか
+func isPrivateMemory(dsn string) bool {
+	memory := dsn == ":memory:" || strings.Contains(dsn, "mode=memory") || strings.HasPrefix(dsn, "file::memory:")
+	return memory && !strings.Contains(dsn, "cache=shared")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func orDefault(val, defVal string) string {
+	if val != "" {
+		return val
+	}
+	return defVal
+}
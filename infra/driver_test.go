@@ -0,0 +1,134 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver 実行したSQLを記録するテスト用のドライバ
+//
+// `setDown`で指定した名前のNodeへの接続と疎通確認は失敗します。
+// `setCommitFail`で指定した名前のNodeのコミットは失敗します。
+type (
+	fakeDriver struct{}
+	fakeConn   struct{ name string }
+	fakeTx     struct{ conn *fakeConn }
+)
+
+var (
+	fakeMu   sync.Mutex
+	fakeDown = map[string]bool{}
+	fakeFail = map[string]bool{}
+	fakeLog  = map[string][]string{}
+)
+
+func init() {
+	sql.Register("infra_fake", fakeDriver{})
+	postgres, _ := gorm.GetDialect("postgres")
+	gorm.RegisterDialect("infra_fake", postgres)
+}
+
+func setDown(name string, down bool) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	fakeDown[name] = down
+}
+
+func setCommitFail(name string, fail bool) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	fakeFail[name] = fail
+}
+
+func isDown(name string) bool {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return fakeDown[name]
+}
+
+func record(name, query string) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	fakeLog[name] = append(fakeLog[name], query)
+}
+
+// executed 実行されたSQLを返し、記録を消去します。
+func executed(name string) []string {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	log := fakeLog[name]
+	delete(fakeLog, name)
+	return log
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	if isDown(name) {
+		return nil, errors.New("connection refused")
+	}
+	return &fakeConn{name}, nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	record(c.name, "BEGIN")
+	return &fakeTx{c}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	record(c.name, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	if isDown(c.name) {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (tx *fakeTx) Commit() error {
+	record(tx.conn.name, "COMMIT")
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	if fakeFail[tx.conn.name] {
+		return errors.New("commit failed")
+	}
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	record(tx.conn.name, "ROLLBACK")
+	return nil
+}
+
+func fakeNode(t *testing.T, name string) *node {
+	sqlDB, err := sql.Open("infra_fake", name)
+	assert.Nil(t, err)
+	db, err := gorm.Open("postgres", sqlDB)
+	assert.Nil(t, err)
+	return newNode(name, db)
+}
+
+func fakeDB(t *testing.T, policy ReplicaPolicy, replicas ...string) *DB {
+	db := newDB(&Config{ReplicaPolicy: policy, Retry: RetryConfig{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}, Logger: logger.New("infra_test", logger.Debug)}, fakeNode(t, "primary"))
+	for _, name := range replicas {
+		db.replicas = append(db.replicas, fakeNode(t, name))
+	}
+	return db
+}
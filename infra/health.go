@@ -0,0 +1,29 @@
+package infra
+
+import (
+	"context"
+
+	"github.com/belldata-dx/bdx/health"
+)
+
+func (n *node) healthCheck(optional bool) *health.Check {
+	return &health.Check{
+		Name: "db:" + n.name,
+		Func: func(ctx context.Context) error {
+			return n.db.DB().PingContext(ctx)
+		},
+		Optional: optional,
+	}
+}
+
+// HealthChecks 全Nodeへの疎通確認を行うヘルスチェック
+//
+// ReadOnly Nodeは失敗してもReadWrite Nodeへフォールバックするため`Optional`になります。
+//     h.Register(db.HealthChecks()...)
+func (db *DB) HealthChecks() []*health.Check {
+	checks := []*health.Check{db.primary.healthCheck(false)}
+	for _, n := range db.replicas {
+		checks = append(checks, n.healthCheck(true))
+	}
+	return checks
+}
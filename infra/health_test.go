@@ -0,0 +1,29 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/belldata-dx/bdx/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthChecks(t *testing.T) {
+	db := fakeDB(t, "", "health1")
+	defer db.Close()
+	h := health.New()
+	h.CacheTTL = -1
+	h.Register(db.HealthChecks()...)
+
+	report := h.Readiness(context.Background())
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Equal(t, "db:primary", report.Checks[0].Name)
+	assert.Equal(t, "db:health1", report.Checks[1].Name)
+
+	setDown("health1", true)
+	defer setDown("health1", false)
+	report = h.Readiness(context.Background())
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Equal(t, health.StatusDown, report.Checks[1].Status)
+	assert.True(t, report.Checks[1].Optional)
+}
@@ -1,10 +1,8 @@
 package infra
 
-import (
-	"github.com/jinzhu/gorm"
-)
-
+// RepositoryImple リポジトリの実装に埋め込む構造体
+//
+// `Reader()`と`Writer()`で接続先を取得できます。
 type RepositoryImple struct {
-	Master *gorm.DB
-	Slave  *gorm.DB
+	*DB
 }
@@ -0,0 +1,199 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	defaultPerPage = 20
+	defaultMaxPage = 100
+)
+
+// ErrInvalidListQuery 一覧取得のクエリパラメータが不正
+var ErrInvalidListQuery = errors.New("一覧取得のクエリパラメータが不正です。")
+
+type (
+	// ListOptions 一覧取得で許可する項目
+	ListOptions struct {
+		// SortFields `sort`に指定できるカラム
+		SortFields []string
+		// FilterFields `filter[field]`に指定できるカラム
+		FilterFields []string
+		// DefaultSort `sort`が未指定の場合の並び順(`-id`のように`-`を付けると降順、`name,-id`のように`,`区切りで複数指定)
+		DefaultSort string
+		// DefaultPerPage `per_page`が未指定の場合の件数(デフォルト20)
+		DefaultPerPage int
+		// MaxPerPage `per_page`の上限(デフォルト100)
+		MaxPerPage int
+	}
+
+	// SortField 並び順
+	SortField struct {
+		Field string
+		Desc  bool
+	}
+
+	// ListQuery 一覧取得の条件
+	ListQuery struct {
+		Page    int
+		PerPage int
+		Sort    []SortField
+		// Filters カラム毎の値(複数の場合はIN)
+		Filters map[string][]string
+	}
+)
+
+func contains(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePositive(c interfaces.Context, key string, defVal int) (int, error) {
+	val, ok := c.GetQuery(key)
+	if !ok || val == "" {
+		return defVal, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil || i < 1 {
+		return 0, fmt.Errorf("%w %s=%s", ErrInvalidListQuery, key, val)
+	}
+	return i, nil
+}
+
+// sortFields `SortFields`と`DefaultSort`に含まれるカラム
+func (opts ListOptions) sortFields() []string {
+	fields := make([]string, len(opts.SortFields), len(opts.SortFields)+1)
+	copy(fields, opts.SortFields)
+	for _, field := range strings.Split(opts.DefaultSort, ",") {
+		if field = strings.TrimPrefix(strings.TrimSpace(field), "-"); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func parseSort(values []string, allowed []string) ([]SortField, error) {
+	sort := []SortField{}
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			s := SortField{Field: field}
+			if strings.HasPrefix(field, "-") {
+				s = SortField{Field: field[1:], Desc: true}
+			}
+			if !contains(allowed, s.Field) {
+				return nil, fmt.Errorf("%w sort=%s", ErrInvalidListQuery, s.Field)
+			}
+			sort = append(sort, s)
+		}
+	}
+	return sort, nil
+}
+
+// ParseListQuery `page`、`per_page`、`sort`、`filter[field]`のクエリパラメータから一覧取得の条件を生成します。
+//
+// `ListOptions`で許可されていないカラムが指定された場合は`ErrInvalidListQuery`を返します。
+//     GET /students?page=2&per_page=10&sort=name,-id&filter[grade]=1&filter[grade]=2
+func ParseListQuery(c interfaces.Context, opts ListOptions) (*ListQuery, error) {
+	perPage := opts.DefaultPerPage
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	maxPerPage := opts.MaxPerPage
+	if maxPerPage <= 0 {
+		maxPerPage = defaultMaxPage
+	}
+
+	q := &ListQuery{Filters: map[string][]string{}}
+	var err error
+	if q.Page, err = parsePositive(c, "page", 1); err != nil {
+		return nil, err
+	}
+	if q.PerPage, err = parsePositive(c, "per_page", perPage); err != nil {
+		return nil, err
+	}
+	if q.PerPage > maxPerPage {
+		q.PerPage = maxPerPage
+	}
+
+	sort := c.QueryArray("sort")
+	if len(sort) == 0 && opts.DefaultSort != "" {
+		sort = []string{opts.DefaultSort}
+	}
+	if q.Sort, err = parseSort(sort, opts.sortFields()); err != nil {
+		return nil, err
+	}
+
+	for key := range c.Request().URL.Query() {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		field := key[len("filter[") : len(key)-1]
+		if !contains(opts.FilterFields, field) {
+			return nil, fmt.Errorf("%w filter[%s]", ErrInvalidListQuery, field)
+		}
+		q.Filters[field] = c.QueryArray(key)
+	}
+	return q, nil
+}
+
+// Offset 取得開始位置
+func (q *ListQuery) Offset() int {
+	return (q.Page - 1) * q.PerPage
+}
+
+// where 絞り込み条件を適用します。
+func (q *ListQuery) where(db *gorm.DB) *gorm.DB {
+	for _, field := range sortedFilterKeys(q.Filters) {
+		values := q.Filters[field]
+		if len(values) == 1 {
+			db = db.Where(field+" = ?", values[0])
+		} else {
+			db = db.Where(field+" IN (?)", values)
+		}
+	}
+	return db
+}
+
+func sortedFilterKeys(m map[string][]string) []string {
+	keys := make(map[string]string, len(m))
+	for key := range m {
+		keys[key] = key
+	}
+	return sortedKeys(keys)
+}
+
+// List `q`の条件でReadOnly Node(トランザクション中はそのトランザクション)から`out`へ取得し、絞り込み後の総件数を返します。
+//
+// `out`はモデルのスライスのポインタを指定します。`scopes`で追加の条件を指定できます。
+//     q, err := infra.ParseListQuery(c, infra.ListOptions{SortFields: []string{"id", "name"}})
+//     var students []*StudentModel
+//     total, err := repo.List(ctx, q, &students)
+func (r RepositoryImple) List(ctx context.Context, q *ListQuery, out interface{}, scopes ...func(*gorm.DB) *gorm.DB) (total int64, err error) {
+	db := q.where(r.ReadConn(ctx).Model(out).Scopes(scopes...))
+	if err = db.Count(&total).Error; err != nil {
+		return 0, err
+	}
+	for _, s := range q.Sort {
+		if s.Desc {
+			db = db.Order(s.Field + " DESC")
+		} else {
+			db = db.Order(s.Field)
+		}
+	}
+	err = db.Offset(q.Offset()).Limit(q.PerPage).Find(out).Error
+	return total, err
+}
@@ -0,0 +1,88 @@
+package infra
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx"
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+type gradeModel struct {
+	ID    int
+	Name  string
+	Grade int
+}
+
+func TestList(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	assert.Nil(t, db.Writer().AutoMigrate(&gradeModel{}).Error)
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		assert.Nil(t, db.Writer().Create(&gradeModel{Name: name, Grade: i%2 + 1}).Error)
+	}
+	repo := RepositoryImple{DB: db}
+	opts := ListOptions{SortFields: []string{"name"}, FilterFields: []string{"grade"}, DefaultSort: "id", MaxPerPage: 3}
+
+	router := bdx.New()
+	router.GET("/grades", func(c interfaces.Context) {
+		q, err := ParseListQuery(c, opts)
+		if err != nil {
+			assert.True(t, errors.Is(err, ErrInvalidListQuery))
+			c.JSON(http.StatusBadRequest, bdx.B{"error": err.Error()})
+			return
+		}
+		var result []*gradeModel
+		total, err := repo.List(c.Request().Context(), q, &result)
+		assert.Nil(t, err)
+		c.Paginated(http.StatusOK, render.Paginated{Data: result, Page: q.Page, PerPage: q.PerPage, Total: total})
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/grades?per_page=2&sort=-name&filter[grade]=1")
+	body, _ := ioutil.ReadAll(w.Body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"data":[{"ID":5,"Name":"e","Grade":1},{"ID":3,"Name":"c","Grade":1}],"pagination":{"page":1,"per_page":2,"total":3,"total_pages":2}}`, string(body))
+	// `w.Header()`はWriteHeaderの後に設定したヘッダーも返すため、送信されたヘッダーで確認する
+	assert.Equal(t, "3", w.Result().Header.Get("X-Total-Count"))
+	assert.Contains(t, w.Result().Header.Get("Link"), `rel="next"`)
+
+	w = get("/grades?page=2&per_page=10&filter[grade]=1&filter[grade]=2")
+	body, _ = ioutil.ReadAll(w.Body)
+	assert.Equal(t, `{"data":[{"ID":4,"Name":"d","Grade":2},{"ID":5,"Name":"e","Grade":1}],"pagination":{"page":2,"per_page":3,"total":5,"total_pages":2}}`, string(body))
+
+	for _, path := range []string{"/grades?sort=password", "/grades?filter[name]=a", "/grades?page=0", "/grades?per_page=x"} {
+		assert.Equal(t, http.StatusBadRequest, get(path).Code, path)
+	}
+}
+
+func TestParseListQueryDefaultSort(t *testing.T) {
+	fields := make([]string, 1, 2)
+	fields[0] = "name"
+	opts := ListOptions{SortFields: fields, DefaultSort: "grade, -id"}
+
+	var q *ListQuery
+	var err error
+	router := bdx.New()
+	router.GET("/grades", func(c interfaces.Context) {
+		q, err = ParseListQuery(c, opts)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/grades", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []SortField{{Field: "grade"}, {Field: "id", Desc: true}}, q.Sort)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/grades?sort=-id,name", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []SortField{{Field: "id", Desc: true}, {Field: "name"}}, q.Sort)
+	// 呼び出し元の`SortFields`は変更しない
+	assert.Equal(t, []string{"name", ""}, fields[:2])
+}
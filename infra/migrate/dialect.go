@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/belldata-dx/bdx/infra"
+)
+
+// quoteIdent 識別子をDialectに合わせてクォートします。
+func quoteIdent(dialect infra.Dialect, ident string) string {
+	if dialect == infra.MySQL {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// placeholder `n`番目(1始まり)のバインド変数
+func placeholder(dialect infra.Dialect, n int) string {
+	if dialect == infra.Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// lockKey アドバイザリロックのキー
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// lock 他のインスタンスがマイグレーションを実行しないようにロックを取得します。
+//
+// PostgreSQLは`pg_advisory_lock`、MySQLは`GET_LOCK`を使用します。
+// SQLiteはDBファイル自体のロックに任せるため何もしません。
+func lock(ctx context.Context, dialect infra.Dialect, conn *sql.Conn, name string) error {
+	switch dialect {
+	case infra.Postgres:
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey(name))
+		return err
+	case infra.MySQL:
+		var ok sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", name).Scan(&ok); err != nil {
+			return err
+		}
+		if ok.Int64 != 1 {
+			return fmt.Errorf("ロック %s を取得できませんでした。", name)
+		}
+	}
+	return nil
+}
+
+func unlock(ctx context.Context, dialect infra.Dialect, conn *sql.Conn, name string) error {
+	switch dialect {
+	case infra.Postgres:
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey(name))
+		return err
+	case infra.MySQL:
+		_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name)
+		return err
+	}
+	return nil
+}
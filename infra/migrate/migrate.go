@@ -0,0 +1,238 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/infra"
+)
+
+// DefaultTable 適用済みバージョンを記録するテーブルのデフォルト名
+const DefaultTable = "schema_migrations"
+
+type (
+	// Migrator バージョン管理されたSQLファイルでスキーマをマイグレーションします。
+	//
+	// マイグレーションファイルは`0001_create_students.up.sql`、`0001_create_students.down.sql`
+	// のように`バージョン_名前.up.sql`、`バージョン_名前.down.sql`の形式で配置します。
+	// 適用済みのバージョンは`infra.Config.Schema`のスキーマ内の`Table`に記録されます。
+	Migrator struct {
+		// Table 適用済みバージョンを記録するテーブル名
+		Table string
+		// Logger マイグレーションのログ出力に使用するlogger
+		Logger logger.ILogger
+
+		db         *infra.DB
+		migrations []*Migration
+	}
+
+	// Status マイグレーションの適用状況
+	Status struct {
+		Version   int64      `json:"version"`
+		Name      string     `json:"name"`
+		Applied   bool       `json:"applied"`
+		AppliedAt *time.Time `json:"applied_at,omitempty"`
+	}
+)
+
+// New Migratorコンストラクタ
+//
+// `source`のルートディレクトリからマイグレーションファイルを読み込みます。
+// ディレクトリから読み込む場合は`Dir`、バイナリに埋め込む場合は埋め込みツールが生成する`http.FileSystem`を指定します。
+//     m, err := migrate.New(db, migrate.Dir("./migrations"))
+func New(db *infra.DB, source http.FileSystem) (*Migrator, error) {
+	migrations, err := load(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		Table:      DefaultTable,
+		Logger:     db.Logger(),
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrations 読み込まれたマイグレーション
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// table スキーマ付きのテーブル名
+func (m *Migrator) table() string {
+	dialect := m.db.Dialect()
+	table := quoteIdent(dialect, m.Table)
+	if schema := m.db.Schema(); schema != "" && dialect == infra.Postgres {
+		return quoteIdent(dialect, schema) + "." + table
+	}
+	return table
+}
+
+func (m *Migrator) lockName() string {
+	return "bdx_migrate:" + m.db.Schema() + "." + m.Table
+}
+
+// session ロックを取得した接続で`fn`を実行します。
+func (m *Migrator) session(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.db.Writer().DB().Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("マイグレーション用の接続エラー: %w", err)
+	}
+	defer conn.Close()
+
+	dialect := m.db.Dialect()
+	name := m.lockName()
+	if err = lock(ctx, dialect, conn, name); err != nil {
+		return fmt.Errorf("マイグレーションのロック取得エラー: %w", err)
+	}
+	defer func() {
+		if err := unlock(context.Background(), dialect, conn, name); err != nil {
+			m.Logger.Errorf("マイグレーションのロック解放エラー: %v", err)
+		}
+	}()
+
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)", m.table())
+	if _, err = conn.ExecContext(ctx, ddl); err != nil {
+		return fmt.Errorf("%s の作成エラー: %w", m.Table, err)
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s", m.table()))
+	if err != nil {
+		return nil, fmt.Errorf("適用済みバージョンの取得エラー: %w", err)
+	}
+	defer rows.Close()
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("適用済みバージョンの取得エラー: %w", err)
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// exec トランザクション内でマイグレーションを実行し、適用済みバージョンを更新します。
+// ファイルは1回の`ExecContext`で実行するため、MySQLではDSNに`multiStatements=true`が必要です(`infra.Open`は既定で追加します)。
+func (m *Migrator) exec(ctx context.Context, conn *sql.Conn, mig *Migration, up bool) error {
+	direction, body := "up", mig.Up
+	if !up {
+		direction, body = "down", mig.Down
+		if body == "" {
+			return fmt.Errorf("バージョン %d のdownファイルがありません。", mig.Version)
+		}
+	}
+	m.Logger.Infof("マイグレーション %d_%s (%s) を実行します。", mig.Version, mig.Name, direction)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, body); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("マイグレーション %d_%s (%s) の実行エラー: %w", mig.Version, mig.Name, direction, err)
+	}
+	dialect := m.db.Dialect()
+	if up {
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)", m.table(),
+				placeholder(dialect, 1), placeholder(dialect, 2), placeholder(dialect, 3)),
+			mig.Version, mig.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.table(), placeholder(dialect, 1)),
+			mig.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("適用済みバージョンの更新エラー: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Up 未適用のマイグレーションを全て適用します。
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.latest())
+}
+
+// Down 最後に適用されたマイグレーションを1つ戻します。
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.session(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.exec(ctx, conn, m.migrations[i], false)
+			}
+		}
+		return nil
+	})
+}
+
+// To 指定したバージョンまでマイグレーションを適用、もしくは戻します。
+//
+// `version`より新しい適用済みのマイグレーションは新しい順に戻され、
+// `version`以下の未適用のマイグレーションは古い順に適用されます。
+// `version`に0を指定した場合は全て戻します。
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	return m.session(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.exec(ctx, conn, mig, false); err != nil {
+					return err
+				}
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.exec(ctx, conn, mig, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status 全てのマイグレーションの適用状況を返します。
+func (m *Migrator) Status(ctx context.Context) (status []Status, err error) {
+	err = m.session(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		status = make([]Status, 0, len(m.migrations))
+		for _, mig := range m.migrations {
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if at, ok := applied[mig.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = &at
+			}
+			status = append(status, s)
+		}
+		return nil
+	})
+	return
+}
+
+// Startup 未適用のマイグレーションを全て適用します。
+//
+// `Engine.OnStart`に登録するとサーバ起動時にマイグレーションを実行できます。
+//     router.OnStart(m.Startup)
+func (m *Migrator) Startup() error {
+	return m.Up(context.Background())
+}
+
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
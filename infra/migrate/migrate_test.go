@@ -0,0 +1,107 @@
+package migrate_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/belldata-dx/bdx/infra"
+	"github.com/belldata-dx/bdx/infra/migrate"
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var files = map[string]string{
+	"0001_create_students.up.sql":   "CREATE TABLE students (id INTEGER PRIMARY KEY, name TEXT);",
+	"0001_create_students.down.sql": "DROP TABLE students;",
+	"0002_create_parents.up.sql":    "CREATE TABLE parents (id INTEGER PRIMARY KEY, name TEXT);",
+	"0002_create_parents.down.sql":  "DROP TABLE parents;",
+	"0003_add_age.up.sql":           "ALTER TABLE students ADD COLUMN age INTEGER;",
+	"README.md":                     "ignored",
+}
+
+func migrationDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0644)
+	}
+	return dir
+}
+
+func tables(t *testing.T, db *infra.DB) []string {
+	names := []string{}
+	rows, err := db.Writer().Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name").Rows()
+	assert.Nil(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestMigrate(t *testing.T) {
+	dir := migrationDir(t, files)
+	defer os.RemoveAll(dir)
+	sqlitetest.Skip(t)
+	db, err := infra.Open(&infra.Config{Dialect: infra.SQLite})
+	assert.Nil(t, err)
+	defer db.Close()
+	m, err := migrate.New(db, migrate.Dir(dir))
+	assert.Nil(t, err)
+	assert.Len(t, m.Migrations(), 3)
+	ctx := context.Background()
+
+	assert.Nil(t, m.Up(ctx))
+	assert.Equal(t, []string{"parents", "schema_migrations", "students"}, tables(t, db))
+	status, err := m.Status(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, status, 3)
+	for _, s := range status {
+		assert.True(t, s.Applied)
+		assert.NotNil(t, s.AppliedAt)
+	}
+
+	// 3はdownファイルが無いため戻せない
+	assert.NotNil(t, m.Down(ctx))
+
+	assert.Nil(t, m.Startup())
+	status, _ = m.Status(ctx)
+	assert.True(t, status[2].Applied)
+
+	os.Remove(filepath.Join(dir, "0003_add_age.up.sql"))
+	m2, err := migrate.New(db, migrate.Dir(dir))
+	assert.Nil(t, err)
+	assert.Nil(t, m2.Down(ctx))
+	assert.Equal(t, []string{"schema_migrations", "students"}, tables(t, db))
+	assert.Nil(t, m2.To(ctx, 0))
+	assert.Equal(t, []string{"schema_migrations"}, tables(t, db))
+	assert.Nil(t, m2.To(ctx, 1))
+	assert.Equal(t, []string{"schema_migrations", "students"}, tables(t, db))
+	status, _ = m2.Status(ctx)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+}
+
+func TestMigrateSourceError(t *testing.T) {
+	dir := migrationDir(t, map[string]string{
+		"0001_a.up.sql": "",
+		"0001_b.up.sql": "",
+	})
+	defer os.RemoveAll(dir)
+	sqlitetest.Skip(t)
+	db, err := infra.Open(&infra.Config{Dialect: infra.SQLite})
+	assert.Nil(t, err)
+	defer db.Close()
+	_, err = migrate.New(db, migrate.Dir(dir))
+	assert.NotNil(t, err)
+
+	_, err = migrate.New(db, migrate.Dir(filepath.Join(dir, "none")))
+	assert.NotNil(t, err)
+}
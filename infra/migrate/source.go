@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Migration バージョン毎のマイグレーション
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// fileName `0001_create_students.up.sql`形式のファイル名
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Dir ディレクトリのマイグレーションファイルを読み込む`http.FileSystem`
+func Dir(dir string) http.FileSystem {
+	return http.Dir(dir)
+}
+
+// load `source`のルートディレクトリからマイグレーションファイルを読み込み、バージョンの昇順に返します。
+//
+// 形式に合わないファイル名は無視します。
+func load(source http.FileSystem) ([]*Migration, error) {
+	dir, err := source.Open("/")
+	if err != nil {
+		return nil, fmt.Errorf("マイグレーションディレクトリのオープンエラー: %w", err)
+	}
+	defer dir.Close()
+	infos, err := dir.Readdir(-1)
+	if err != nil {
+		return nil, fmt.Errorf("マイグレーションディレクトリの読み込みエラー: %w", err)
+	}
+
+	migrations := map[int64]*Migration{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(info.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: バージョンの解析エラー: %w", info.Name(), err)
+		}
+		m, ok := migrations[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			migrations[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("バージョン %d が重複しています。(%s, %s)", version, m.Name, match[2])
+		}
+		body, err := readFile(source, info.Name())
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.Up = body
+		} else {
+			m.Down = body
+		}
+	}
+
+	list := make([]*Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if m.Up == "" {
+			return nil, fmt.Errorf("バージョン %d のupファイルがありません。", m.Version)
+		}
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+func readFile(source http.FileSystem, name string) (string, error) {
+	f, err := source.Open(path.Join("/", name))
+	if err != nil {
+		return "", fmt.Errorf("%s のオープンエラー: %w", name, err)
+	}
+	defer f.Close()
+	buf, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("%s の読み込みエラー: %w", name, err)
+	}
+	return string(buf), nil
+}
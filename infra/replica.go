@@ -0,0 +1,187 @@
+package infra
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// node DBの接続先1つ分
+type node struct {
+	name    string
+	db      *gorm.DB
+	healthy int32
+	latency int64
+	log     *sqlLogger
+
+	// attempt 切り離し後の再接続の試行回数
+	attempt int
+	// retryAt 次に再接続を試行する時刻(UnixNano)
+	retryAt int64
+	// retrying `Reader()`からの再接続を試行中か
+	retrying int32
+}
+
+func newNode(name string, db *gorm.DB) *node {
+	return &node{name: name, db: db, healthy: 1}
+}
+
+func (n *node) isHealthy() bool {
+	return atomic.LoadInt32(&n.healthy) == 1
+}
+
+func (n *node) lastLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.latency))
+}
+
+// ping 疎通確認を行い、結果を記録します。
+// 状態が変化した場合は`true`を返します。
+func (n *node) ping(timeout time.Duration) (changed bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	err = n.db.DB().PingContext(ctx)
+	var healthy int32
+	if err == nil {
+		healthy = 1
+		atomic.StoreInt64(&n.latency, int64(time.Since(start)))
+	}
+	return atomic.SwapInt32(&n.healthy, healthy) != healthy, err
+}
+
+// retryDue 切り離したNodeへの再接続を試行する時刻を過ぎているか
+func (n *node) retryDue(now time.Time) bool {
+	return !n.isHealthy() && now.UnixNano() >= atomic.LoadInt64(&n.retryAt)
+}
+
+// pick `ReplicaPolicy`に従って正常なReadOnly Nodeを選択します。
+// 正常なNodeが無い場合は`nil`を返します。
+func (db *DB) pick() *node {
+	healthy := make([]*node, 0, len(db.replicas))
+	for _, n := range db.replicas {
+		if n.isHealthy() {
+			healthy = append(healthy, n)
+		} else if db.lazyRetry {
+			db.retryLazily(n)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	switch db.policy {
+	case Random:
+		return healthy[rand.Intn(len(healthy))]
+	case LeastLatency:
+		best := healthy[0]
+		for _, n := range healthy[1:] {
+			if n.lastLatency() < best.lastLatency() {
+				best = n
+			}
+		}
+		return best
+	default:
+		i := atomic.AddUint64(&db.counter, 1) - 1
+		return healthy[i%uint64(len(healthy))]
+	}
+}
+
+// detach 切り離した状態にし、次の再接続を指数バックオフで予定します。
+func (n *node) detach(retry RetryConfig) time.Duration {
+	atomic.StoreInt32(&n.healthy, 0)
+	n.attempt++
+	wait := retry.backoff(n.attempt)
+	atomic.StoreInt64(&n.retryAt, time.Now().Add(wait).UnixNano())
+	return wait
+}
+
+// checkReplica ReadOnly Nodeへヘルスチェックを行い、失敗した場合は切り離します。
+func (db *DB) checkReplica(n *node, timeout time.Duration) {
+	changed, err := n.ping(timeout)
+	switch {
+	case err != nil && changed:
+		wait := n.detach(db.retry)
+		db.log.Warnf("ReadOnly Node(%s)を切り離します。%v後に再接続を試行します: %v", n.name, wait, err)
+	case err != nil:
+		wait := n.detach(db.retry)
+		db.log.Warnf("ReadOnly Node(%s)への再接続に失敗しました(%d回目)。%v後に再試行します: %v", n.name, n.attempt-1, wait, err)
+	case changed:
+		db.log.Infof("ReadOnly Node(%s)が復帰しました(%d回目)。", n.name, n.attempt)
+		n.attempt = 0
+	}
+}
+
+// checkReplicas 切り離したNodeのうち再接続を試行する時刻を過ぎたNodeへ再接続を試行します。
+// `all`の場合は正常なNodeへもヘルスチェックを行います。
+func (db *DB) checkReplicas(timeout time.Duration, all bool) {
+	now := time.Now()
+	for _, n := range db.replicas {
+		if n.isHealthy() {
+			if !all {
+				continue
+			}
+		} else if !n.retryDue(now) {
+			continue
+		}
+		db.checkReplica(n, timeout)
+	}
+}
+
+// nextCheck 次のヘルスチェックの時刻`next`と、切り離したNodeへの再接続の時刻のうち早い方までの時間
+func (db *DB) nextCheck(next time.Time) time.Duration {
+	wake := next.UnixNano()
+	for _, n := range db.replicas {
+		if retryAt := atomic.LoadInt64(&n.retryAt); !n.isHealthy() && retryAt < wake {
+			wake = retryAt
+		}
+	}
+	return time.Until(time.Unix(0, wake))
+}
+
+// retryLazily ヘルスチェックが無効な場合に、再接続を試行する時刻を過ぎたNodeへバックグラウンドで再接続を試行します。
+func (db *DB) retryLazily(n *node) {
+	if !n.retryDue(time.Now()) || !atomic.CompareAndSwapInt32(&n.retrying, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&n.retrying, 0)
+		db.checkReplica(n, db.healthTimeout)
+	}()
+}
+
+// startHealthCheck `interval`毎に全てのReadOnly Nodeへヘルスチェックを行います。
+// 切り離したNodeへは`interval`を待たずに指数バックオフの時刻で再接続を試行します。
+//
+// `interval`が負の場合はヘルスチェックを行わず、`Reader()`の呼び出し時に切り離したNodeへ再接続を試行します。
+func (db *DB) startHealthCheck(interval, timeout time.Duration) {
+	db.healthTimeout = timeout
+	if len(db.replicas) == 0 {
+		return
+	}
+	if interval < 0 {
+		db.lazyRetry = true
+		return
+	}
+	db.wg.Add(1)
+	go func() {
+		defer db.wg.Done()
+		next := time.Now().Add(interval)
+		timer := time.NewTimer(db.nextCheck(next))
+		defer timer.Stop()
+		for {
+			select {
+			case <-db.stop:
+				return
+			case <-timer.C:
+			}
+			all := !time.Now().Before(next)
+			if all {
+				next = time.Now().Add(interval)
+			}
+			db.checkReplicas(timeout, all)
+			timer.Reset(db.nextCheck(next))
+		}
+	}()
+}
@@ -0,0 +1,100 @@
+package infra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderRoundRobin(t *testing.T) {
+	db := fakeDB(t, "", "r1", "r2")
+	defer db.Close()
+	assert.Equal(t, db.replicas[0].db, db.Reader())
+	assert.Equal(t, db.replicas[1].db, db.Reader())
+	assert.Equal(t, db.replicas[0].db, db.Reader())
+	assert.Equal(t, db.primary.db, db.Writer())
+}
+
+func TestReaderLeastLatency(t *testing.T) {
+	db := fakeDB(t, LeastLatency, "r1", "r2")
+	defer db.Close()
+	db.replicas[0].latency = int64(20 * time.Millisecond)
+	db.replicas[1].latency = int64(10 * time.Millisecond)
+	assert.Equal(t, db.replicas[1].db, db.Reader())
+	assert.Equal(t, db.replicas[1].db, db.Reader())
+}
+
+func TestReaderRandom(t *testing.T) {
+	db := fakeDB(t, Random, "r1", "r2")
+	defer db.Close()
+	for i := 0; i < 10; i++ {
+		r := db.Reader()
+		assert.True(t, r == db.replicas[0].db || r == db.replicas[1].db)
+	}
+}
+
+func TestReaderWithoutReplica(t *testing.T) {
+	db := fakeDB(t, "")
+	defer db.Close()
+	assert.Equal(t, db.primary.db, db.Reader())
+}
+
+func TestReplicaFailover(t *testing.T) {
+	db := fakeDB(t, "", "failover1", "failover2")
+	defer db.Close()
+	db.startHealthCheck(5*time.Millisecond, time.Second)
+
+	setDown("failover1", true)
+	defer setDown("failover1", false)
+	assert.Eventually(t, func() bool {
+		return !db.replicas[0].isHealthy()
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, db.replicas[1].db, db.Reader())
+	assert.Equal(t, db.replicas[1].db, db.Reader())
+
+	setDown("failover2", true)
+	defer setDown("failover2", false)
+	assert.Eventually(t, func() bool {
+		return !db.replicas[1].isHealthy()
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, db.primary.db, db.Reader())
+
+	setDown("failover1", false)
+	assert.Eventually(t, func() bool {
+		return db.replicas[0].isHealthy()
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, db.replicas[0].db, db.Reader())
+}
+
+func TestReplicaRetryBeforeHealthCheck(t *testing.T) {
+	db := fakeDB(t, "", "retry_tick")
+	defer db.Close()
+	setDown("retry_tick", true)
+	defer setDown("retry_tick", false)
+	db.replicas[0].detach(db.retry)
+	// 再接続はヘルスチェックの間隔を待たずにバックオフの時刻で試行する
+	db.startHealthCheck(time.Hour, time.Second)
+
+	time.Sleep(20 * time.Millisecond)
+	setDown("retry_tick", false)
+	assert.Eventually(t, func() bool {
+		return db.replicas[0].isHealthy()
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestReplicaRetryWithoutHealthCheck(t *testing.T) {
+	db := fakeDB(t, "", "retry_lazy")
+	defer db.Close()
+	setDown("retry_lazy", true)
+	defer setDown("retry_lazy", false)
+	db.replicas[0].detach(db.retry)
+	db.startHealthCheck(-1, time.Second)
+	assert.Equal(t, db.primary.db, db.Reader())
+
+	// ヘルスチェックが無効な場合はReader()の呼び出し時に再接続する
+	setDown("retry_lazy", false)
+	assert.Eventually(t, func() bool {
+		return db.Reader() == db.replicas[0].db
+	}, time.Second, 5*time.Millisecond)
+}
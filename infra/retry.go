@@ -0,0 +1,98 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"math/rand"
+	"time"
+
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	defaultRetryInterval    = 500 * time.Millisecond
+	defaultRetryMaxInterval = 10 * time.Second
+	defaultRetryMultiplier  = 2
+	defaultRetryJitter      = 0.2
+)
+
+// RetryConfig 接続の再試行設定
+//
+// 起動時の接続とReadOnly Nodeの再接続で指数バックオフによる再試行を行います。
+//     cfg.Retry = infra.RetryConfig{Timeout: time.Minute}
+type RetryConfig struct {
+	// Timeout 起動時の接続を打ち切るまでの時間(0の場合は再試行しない)
+	Timeout time.Duration
+	// Interval 初回の待機時間(デフォルト500ミリ秒)
+	Interval time.Duration
+	// MaxInterval 待機時間の上限(デフォルト10秒)
+	MaxInterval time.Duration
+	// Multiplier 再試行毎に待機時間を増やす倍率(デフォルト2)
+	Multiplier float64
+	// Jitter 待機時間に加える揺らぎの割合(0〜1、デフォルト0.2、負の値で無効)
+	Jitter float64
+}
+
+// backoff `attempt`回目の失敗後の待機時間
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	interval, max, multiplier, jitter := r.Interval, r.MaxInterval, r.Multiplier, r.Jitter
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	if max <= 0 {
+		max = defaultRetryMaxInterval
+	}
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+	if jitter == 0 {
+		jitter = defaultRetryJitter
+	}
+	wait := float64(interval) * math.Pow(multiplier, float64(attempt-1))
+	if wait > float64(max) {
+		wait = float64(max)
+	}
+	if jitter > 0 {
+		wait += wait * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(wait)
+}
+
+// dbOpen 接続を生成し、疎通確認を行います。
+// 疎通確認に失敗した場合も生成した接続をエラーと共に返します。
+func dbOpen(dialect, dsn string) (*gorm.DB, error) {
+	sqlDB, err := sql.Open(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Open(dialect, sqlDB)
+}
+
+// connect `Timeout`に達するまで疎通確認を再試行しながら接続します。
+// 疎通できなかった場合も生成した接続をエラーと共に返します。
+func (r RetryConfig) connect(log logger.ILogger, name, dialect, dsn string) (*gorm.DB, error) {
+	db, err := dbOpen(dialect, dsn)
+	if db == nil || err == nil {
+		return db, err
+	}
+	deadline := time.Now().Add(r.Timeout)
+	for attempt := 1; ; attempt++ {
+		wait := r.backoff(attempt)
+		if time.Now().Add(wait).After(deadline) {
+			log.Errorf("%sへの接続を中止します(%d回失敗): %v", name, attempt, err)
+			return db, err
+		}
+		log.Warnf("%sへの接続に失敗しました(%d回目)。%v後に再試行します: %v", name, attempt, wait, err)
+		time.Sleep(wait)
+
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		err = db.DB().PingContext(ctx)
+		cancel()
+		if err == nil {
+			log.Infof("%sへ接続しました(%d回目)。", name, attempt+1)
+			return db, nil
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package infra
+
+import (
+	"testing"
+	"time"
+
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	r := RetryConfig{Interval: 100 * time.Millisecond, MaxInterval: time.Second, Jitter: -1}
+	waits := []time.Duration{}
+	for attempt := 1; attempt <= 6; attempt++ {
+		waits = append(waits, r.backoff(attempt))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond,
+		800 * time.Millisecond, time.Second, time.Second,
+	}, waits)
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		wait := r.backoff(1)
+		assert.True(t, wait >= 50*time.Millisecond && wait <= 150*time.Millisecond, wait)
+	}
+}
+
+func TestConnectRetry(t *testing.T) {
+	log := logger.New("infra_test", logger.Debug)
+	r := RetryConfig{Timeout: time.Second, Interval: 5 * time.Millisecond}
+
+	setDown("retry1", true)
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		setDown("retry1", false)
+	}()
+	db, err := r.connect(log, "retry1", "infra_fake", "retry1")
+	assert.Nil(t, err)
+	assert.NotNil(t, db)
+	db.Close()
+
+	setDown("retry2", true)
+	defer setDown("retry2", false)
+	r.Timeout = 20 * time.Millisecond
+	start := time.Now()
+	db, err = r.connect(log, "retry2", "infra_fake", "retry2")
+	assert.NotNil(t, err)
+	assert.NotNil(t, db)
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+	db.Close()
+}
+
+func TestLazyReplica(t *testing.T) {
+	fake := fakeDB(t, "", "lazy1")
+	defer fake.Close()
+	setDown("lazy1", true)
+	n := fake.replicas[0]
+	fake.checkReplicas(time.Second, true)
+	assert.False(t, n.isHealthy())
+	assert.Equal(t, 1, n.attempt)
+
+	// バックオフ中は再試行しない
+	n.retryAt = time.Now().Add(time.Hour).UnixNano()
+	setDown("lazy1", false)
+	fake.checkReplicas(time.Second, true)
+	assert.False(t, n.isHealthy())
+
+	n.retryAt = 0
+	fake.checkReplicas(time.Second, true)
+	assert.True(t, n.isHealthy())
+	assert.Equal(t, 0, n.attempt)
+}
@@ -0,0 +1,166 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/interfaces"
+)
+
+// RequestIDHeader SQLのログに出力するリクエストIDのヘッダー
+//
+// リクエストのヘッダー、無ければレスポンスのヘッダー(リクエストIDを発行するミドルウェアが設定したもの)から取得します。
+const RequestIDHeader = "X-Request-Id"
+
+var logLevels = map[string]logger.LogLevel{
+	"DEBUG": logger.Debug,
+	"INFO":  logger.Info,
+	"WARN":  logger.Warn,
+	"ERROR": logger.Error,
+}
+
+// sqlLogger gormのログをbdx-loggerへ出力するアダプター
+type sqlLogger struct {
+	log     logger.ILogger
+	node    string
+	enabled bool
+	level   logger.LogLevel
+	slow    time.Duration
+	redact  bool
+
+	// requestID `bind`したリクエストのID
+	requestID string
+	// skip `ctxConn`で出力済みのため、gormから渡されても出力しないエラー
+	skip *loggedErrors
+}
+
+// loggedErrors `bind`したハンドル毎に`ctxConn`が出力したエラーと回数
+type loggedErrors struct {
+	mu   sync.Mutex
+	errs map[error]int
+}
+
+func newSQLLogger(cfg *Config, log logger.ILogger, node string) *sqlLogger {
+	return &sqlLogger{
+		log:     log,
+		node:    node,
+		enabled: cfg.LogMode,
+		level:   cfg.LogLevel,
+		slow:    cfg.SlowThreshold,
+		redact:  cfg.RedactParams,
+	}
+}
+
+// active gormへログを要求する必要があるか
+func (l *sqlLogger) active() bool {
+	return l.enabled || l.slow > 0
+}
+
+// bound `bind`したハンドル用のsqlLoggerを返します。
+//
+// `ctx`がbdxのリクエストのコンテキストの場合は、engineのloggerとリクエストIDで出力します。
+// また、`ctxConn`が出力したエラーを記録し、gormから渡されても重複して出力しません。
+func (l *sqlLogger) bound(ctx context.Context) *sqlLogger {
+	b := *l
+	b.skip = &loggedErrors{errs: make(map[error]int)}
+	if c, ok := ctx.Value(interfaces.ContextKey).(interfaces.Context); ok {
+		if log := c.Logger(); log != nil {
+			b.log = log
+		}
+		b.requestID = c.Request().Header.Get(RequestIDHeader)
+		if b.requestID == "" {
+			b.requestID = c.Response().Header().Get(RequestIDHeader)
+		}
+	}
+	return &b
+}
+
+// prefix ログの先頭に付けるNode名とリクエストID
+func (l *sqlLogger) prefix() string {
+	if l.requestID == "" {
+		return "[" + l.node + "]"
+	}
+	return "[" + l.node + "] [" + l.requestID + "]"
+}
+
+// logged `ctxConn`が`err`を出力したことを記録します。
+// gormがエラーを出力しない設定の場合は記録しません。
+func (l *sqlLogger) logged(err error) {
+	if l.skip == nil || !l.active() {
+		return
+	}
+	l.skip.mu.Lock()
+	l.skip.errs[err]++
+	l.skip.mu.Unlock()
+}
+
+// consume `err`が`ctxConn`で出力済みであれば記録から取り除き`true`を返します。
+func (l *sqlLogger) consume(err error) bool {
+	if l.skip == nil {
+		return false
+	}
+	l.skip.mu.Lock()
+	defer l.skip.mu.Unlock()
+	switch l.skip.errs[err] {
+	case 0:
+		return false
+	case 1:
+		delete(l.skip.errs, err)
+	default:
+		l.skip.errs[err]--
+	}
+	return true
+}
+
+// Print gormから受け取ったログを出力します。
+//
+// SQLの場合は`"sql", 呼び出し元, 実行時間, SQL, バインドパラメータ, 件数`、
+// それ以外は`"log", 呼び出し元, メッセージ...`の順で渡されます。
+func (l *sqlLogger) Print(v ...interface{}) {
+	if len(v) < 2 {
+		return
+	}
+	if v[0] != "sql" || len(v) < 6 {
+		if err, ok := v[len(v)-1].(error); ok && l.consume(err) {
+			return
+		}
+		l.log.Errorf("%s %v %s", l.prefix(), v[1], fmt.Sprint(v[2:]...))
+		return
+	}
+	duration, _ := v[2].(time.Duration)
+	msg := l.format(duration, v[3], v[4], v[5])
+	if l.slow > 0 && duration >= l.slow {
+		l.log.Warnf("%s slow query(>= %v) %s", l.prefix(), l.slow, msg)
+		return
+	}
+	if l.enabled {
+		l.print(fmt.Sprintf("%s %s", l.prefix(), msg))
+	}
+}
+
+func (l *sqlLogger) format(duration time.Duration, sql, vars, rows interface{}) string {
+	msg := fmt.Sprintf("[%.2fms] %v [rows:%v]", float64(duration.Nanoseconds())/1e6, sql, rows)
+	if args, ok := vars.([]interface{}); ok && len(args) > 0 {
+		if l.redact {
+			return fmt.Sprintf("%s args=[REDACTED x%d]", msg, len(args))
+		}
+		return fmt.Sprintf("%s args=%v", msg, args)
+	}
+	return msg
+}
+
+func (l *sqlLogger) print(msg string) {
+	switch l.level {
+	case logger.Info:
+		l.log.Info(msg)
+	case logger.Warn:
+		l.log.Warn(msg)
+	case logger.Error:
+		l.log.Error(msg)
+	default:
+		l.log.Debug(msg)
+	}
+}
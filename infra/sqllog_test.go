@@ -0,0 +1,108 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx"
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	logger.ILogger
+	lines []string
+}
+
+func (l *recordLogger) add(level, msg string)  { l.lines = append(l.lines, level+" "+msg) }
+func (l *recordLogger) Debug(v ...interface{}) { l.add("DEBUG", fmt.Sprint(v...)) }
+func (l *recordLogger) Info(v ...interface{})  { l.add("INFO", fmt.Sprint(v...)) }
+func (l *recordLogger) Infof(format string, v ...interface{}) {
+	l.add("INFO", fmt.Sprintf(format, v...))
+}
+func (l *recordLogger) Warnf(format string, v ...interface{}) {
+	l.add("WARN", fmt.Sprintf(format, v...))
+}
+func (l *recordLogger) Errorf(format string, v ...interface{}) {
+	l.add("ERROR", fmt.Sprintf(format, v...))
+}
+
+func TestSQLLogger(t *testing.T) {
+	rec := &recordLogger{}
+	sqlitetest.Skip(t)
+	db, err := Open(&Config{Dialect: SQLite, LogMode: true, LogLevel: logger.Info, SlowThreshold: time.Hour, Logger: rec})
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, db.Writer().AutoMigrate(&studentModel{}).Error)
+	rec.lines = nil
+
+	assert.Nil(t, db.Writer().Create(&studentModel{Name: "secret"}).Error)
+	assert.Len(t, rec.lines, 1)
+	assert.Regexp(t, `^INFO \[primary\] \[\d+\.\d{2}ms\] INSERT INTO "student_models" \("name"\) VALUES \(\?\) \[rows:1\] args=\[secret\]$`, rec.lines[0])
+
+	l := newSQLLogger(&Config{RedactParams: true, SlowThreshold: time.Second}, rec, "replica-0")
+	rec.lines = nil
+	l.Print("sql", "db.go:1", 2*time.Second, "SELECT * FROM students WHERE name = $1", []interface{}{"secret"}, int64(3))
+	l.Print("sql", "db.go:1", time.Millisecond, "SELECT 1", []interface{}{}, int64(1))
+	l.Print("log", "db.go:1", "connection refused")
+	assert.Equal(t, []string{
+		"WARN [replica-0] slow query(>= 1s) [2000.00ms] SELECT * FROM students WHERE name = $1 [rows:3] args=[REDACTED x1]",
+		"ERROR [replica-0] db.go:1 connection refused",
+	}, rec.lines)
+	assert.True(t, l.active())
+	assert.False(t, newSQLLogger(&Config{}, rec, "primary").active())
+}
+
+func TestSQLLoggerSkipsLoggedErrors(t *testing.T) {
+	rec := &recordLogger{}
+	l := newSQLLogger(&Config{LogMode: true}, rec, "primary")
+	bound := l.bound(context.Background())
+	bound.logged(context.Canceled)
+	bound.Print("log", "db.go:1", context.Canceled)
+	assert.Empty(t, rec.lines)
+
+	// `ctxConn`を経由しないトランザクションなどのエラーは出力する
+	bound.Print("log", "db.go:1", context.Canceled)
+	l.Print("log", "db.go:1", context.DeadlineExceeded)
+	assert.Equal(t, []string{
+		"ERROR [primary] db.go:1 context canceled",
+		"ERROR [primary] db.go:1 context deadline exceeded",
+	}, rec.lines)
+}
+
+func TestSQLLoggerRequest(t *testing.T) {
+	infraLog, engineLog := &recordLogger{}, &recordLogger{}
+	sqlitetest.Skip(t)
+	db, err := Open(&Config{Dialect: SQLite, LogMode: true, LogLevel: logger.Info, Logger: infraLog})
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, db.Writer().AutoMigrate(&studentModel{}).Error)
+	infraLog.lines = nil
+
+	repo := &studentInfra{RepositoryImple{DB: db}}
+	router := bdx.New()
+	router.SetLogger(engineLog)
+	router.GET("/students", func(c interfaces.Context) {
+		_, err := repo.Find(c.Request().Context())
+		assert.Nil(t, err)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/students", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Empty(t, infraLog.lines)
+	assert.Len(t, engineLog.lines, 1)
+	assert.Regexp(t, `^INFO \[primary\] \[req-1\] \[\d+\.\d{2}ms\] SELECT`, engineLog.lines[0])
+
+	// リクエスト以外のコンテキストはinfraのloggerに出力する
+	_, err = repo.Find(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, infraLog.lines, 1)
+	assert.Regexp(t, `^INFO \[primary\] \[\d+\.\d{2}ms\] SELECT`, infraLog.lines[0])
+}
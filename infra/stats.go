@@ -0,0 +1,111 @@
+package infra
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/jinzhu/gorm"
+)
+
+type (
+	// NodeStats Nodeごとのコネクションプールの統計
+	NodeStats struct {
+		Name    string        `json:"name"`
+		Role    string        `json:"role"`
+		Healthy bool          `json:"healthy"`
+		Latency time.Duration `json:"latency_ns"`
+
+		MaxOpenConnections int           `json:"max_open_connections"`
+		OpenConnections    int           `json:"open_connections"`
+		InUse              int           `json:"in_use"`
+		Idle               int           `json:"idle"`
+		WaitCount          int64         `json:"wait_count"`
+		WaitDuration       time.Duration `json:"wait_duration_ns"`
+		MaxIdleClosed      int64         `json:"max_idle_closed"`
+		MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+	}
+
+	// MetricsHook 全Nodeの統計を受け取る関数
+	//     cfg.MetricsHook = func(stats []infra.NodeStats) {
+	//         for _, s := range stats {
+	//             inUse.WithLabelValues(s.Name).Set(float64(s.InUse))
+	//         }
+	//     }
+	MetricsHook func(stats []NodeStats)
+)
+
+const (
+	rolePrimary = "primary"
+	roleReplica = "replica"
+)
+
+// apply `*sql.DB`へコネクションプールの設定を反映します。
+func (p PoolConfig) apply(db *gorm.DB) {
+	sqlDB := db.DB()
+	if p.MaxOpenConns != 0 {
+		sqlDB.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns != 0 {
+		sqlDB.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime != 0 {
+		sqlDB.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+}
+
+func (n *node) stats(role string) NodeStats {
+	s := n.db.DB().Stats()
+	return NodeStats{
+		Name:               n.name,
+		Role:               role,
+		Healthy:            n.isHealthy(),
+		Latency:            n.lastLatency(),
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
+
+// Stats 全Nodeのコネクションプールの統計
+func (db *DB) Stats() []NodeStats {
+	stats := make([]NodeStats, 0, len(db.replicas)+1)
+	stats = append(stats, db.primary.stats(rolePrimary))
+	for _, n := range db.replicas {
+		stats = append(stats, n.stats(roleReplica))
+	}
+	return stats
+}
+
+// StatsHandler 全Nodeのコネクションプールの統計をJSONで返すハンドラ
+//     router.GET("/debug/db", db.StatsHandler())
+func (db *DB) StatsHandler() interfaces.BdxHandlerFunc {
+	return func(c interfaces.Context) {
+		c.JSON(http.StatusOK, db.Stats())
+	}
+}
+
+func (db *DB) startMetrics(interval time.Duration, hook MetricsHook) {
+	if hook == nil {
+		return
+	}
+	db.wg.Add(1)
+	go func() {
+		defer db.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-db.stop:
+				return
+			case <-ticker.C:
+				hook(db.Stats())
+			}
+		}
+	}()
+}
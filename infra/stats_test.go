@@ -0,0 +1,61 @@
+package infra
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolConfig(t *testing.T) {
+	db := fakeDB(t, "", "pool1")
+	defer db.Close()
+	PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetime: time.Minute}.apply(db.primary.db)
+	PoolConfig{MaxOpenConns: 3}.apply(db.replicas[0].db)
+
+	stats := db.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "primary", stats[0].Role)
+	assert.Equal(t, 10, stats[0].MaxOpenConnections)
+	assert.Equal(t, "replica", stats[1].Role)
+	assert.Equal(t, "pool1", stats[1].Name)
+	assert.Equal(t, 3, stats[1].MaxOpenConnections)
+	assert.True(t, stats[1].Healthy)
+}
+
+func TestMetricsHook(t *testing.T) {
+	db := fakeDB(t, "", "metrics1")
+	received := make(chan []NodeStats, 1)
+	db.startMetrics(5*time.Millisecond, func(stats []NodeStats) {
+		select {
+		case received <- stats:
+		default:
+		}
+	})
+	select {
+	case stats := <-received:
+		assert.Len(t, stats, 2)
+	case <-time.After(time.Second):
+		t.Error("MetricsHook was not called")
+	}
+	assert.Nil(t, db.Close())
+}
+
+func TestStatsHandler(t *testing.T) {
+	db := fakeDB(t, "", "handler1")
+	defer db.Close()
+	router := bdx.New()
+	router.GET("/debug/db", db.StatsHandler())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/db", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	stats := []NodeStats{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "handler1", stats[1].Name)
+}
@@ -0,0 +1,167 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/jinzhu/gorm"
+)
+
+type txKey struct{}
+
+// txState コンテキストに保存されるトランザクション
+type txState struct {
+	tx        *gorm.DB
+	savepoint int
+}
+
+// TxManager ReadWrite Nodeのトランザクションを管理します。
+type TxManager struct {
+	db *DB
+}
+
+// NewTxManager TxManagerコンストラクタ
+func NewTxManager(db *DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// TxFrom コンテキストに保存されているトランザクションを返します。
+func TxFrom(ctx context.Context) (*gorm.DB, bool) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx, true
+	}
+	return nil, false
+}
+
+// WithTx トランザクション内で`fn`を実行します。
+//
+// `fn`がエラーを返す、もしくはpanicした場合はロールバックし、それ以外はコミットします。
+// `ctx`に既にトランザクションがある場合はセーブポイントを作成し、
+// エラーの場合はセーブポイントまでロールバックします。
+func (m *TxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.withSavepoint(ctx, fn)
+	}
+
+	// SQLのログにリクエストIDを付けるため、`ctx`に紐付けたハンドルで開始する
+	tx := m.db.WriterContext(ctx).BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return fmt.Errorf("トランザクションの開始エラー: %w", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		if rErr := tx.Rollback().Error; rErr != nil {
+			return fmt.Errorf("%v (ロールバックエラー: %w)", err, rErr)
+		}
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (state *txState) withSavepoint(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	state.savepoint++
+	name := fmt.Sprintf("bdx_sp_%d", state.savepoint)
+	if err = state.tx.Exec("SAVEPOINT " + name).Error; err != nil {
+		return fmt.Errorf("セーブポイントの作成エラー: %w", err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			state.tx.Exec("ROLLBACK TO SAVEPOINT " + name)
+			panic(r)
+		}
+	}()
+	if err = fn(ctx); err != nil {
+		if rErr := state.tx.Exec("ROLLBACK TO SAVEPOINT " + name).Error; rErr != nil {
+			return fmt.Errorf("%v (ロールバックエラー: %w)", err, rErr)
+		}
+		return err
+	}
+	return state.tx.Exec("RELEASE SAVEPOINT " + name).Error
+}
+
+// Middleware リクエスト毎にトランザクションを開始するミドルウェア
+//
+// 後続のハンドラが`SetError`でエラーを記録した場合、HTTP response codeが400以上の場合、
+// もしくはpanicした場合はロールバックし、それ以外はコミットします。
+// コミットはレスポンスヘッダーを書き込む直前に行うため、`SetError`はレスポンスの書き込み前に呼び出してください。
+// コミットに失敗した場合はエラーを記録してログ出力し、ハンドラのレスポンスの代わりに500を返します。
+// トランザクションは`Request().Context()`に保存され、`RepositoryImple.Conn`で取得できます。
+//     tm := infra.NewTxManager(db)
+//     router.Use(tm.Middleware())
+func (m *TxManager) Middleware() interfaces.BdxHandlerFunc {
+	return func(c interfaces.Context) {
+		req := c.Request()
+		ctx := req.Context()
+		tx := m.db.WriterContext(ctx).BeginTx(ctx, nil)
+		if tx.Error != nil {
+			c.Logger().Errorf("トランザクションの開始エラー: %v", tx.Error)
+			c.AbortWithStatusAndMessage(http.StatusInternalServerError, nil)
+			return
+		}
+
+		done := false
+		// finish トランザクションを終了し、コミットに失敗した場合は`false`を返します。
+		finish := func(status int) bool {
+			if done {
+				return true
+			}
+			done = true
+			if c.Error() != nil || status >= http.StatusBadRequest {
+				if err := tx.Rollback().Error; err != nil {
+					c.Logger().Errorf("トランザクションエラー: %v", err)
+					c.SetError(err)
+				}
+				return true
+			}
+			if err := tx.Commit().Error; err != nil {
+				c.Logger().Errorf("トランザクションエラー: %v", err)
+				c.SetError(err)
+				return false
+			}
+			return true
+		}
+		defer func() {
+			// panicした場合
+			if !done {
+				done = true
+				tx.Rollback()
+			}
+		}()
+		c.BeforeWrite(func() {
+			if !finish(c.ResponseStatus()) {
+				c.SetResponseStatus(http.StatusInternalServerError)
+			}
+		})
+		c.SetRequest(req.WithContext(context.WithValue(ctx, txKey{}, &txState{tx: tx})))
+		c.Next()
+		if c.ResponseStatus() != 0 {
+			return
+		}
+		if !finish(0) {
+			c.AbortWithStatusAndMessage(http.StatusInternalServerError, nil)
+		}
+	}
+}
+
+// Conn コンテキストにトランザクションがあればそれを、無ければコンテキストに紐付いたReadWrite Nodeを返します。
+func (r RepositoryImple) Conn(ctx context.Context) *gorm.DB {
+	if tx, ok := TxFrom(ctx); ok {
+		return tx
+	}
+	return r.WriterContext(ctx)
+}
+
+// ReadConn コンテキストにトランザクションがあればそれを、無ければコンテキストに紐付いたReadOnly Nodeを返します。
+func (r RepositoryImple) ReadConn(ctx context.Context) *gorm.DB {
+	if tx, ok := TxFrom(ctx); ok {
+		return tx
+	}
+	return r.ReaderContext(ctx)
+}
@@ -0,0 +1,159 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx"
+	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+func txDB(t *testing.T, name string) *DB {
+	return newDB(&Config{Logger: logger.New("infra_test", logger.Debug)}, fakeNode(t, name))
+}
+
+func TestWithTx(t *testing.T) {
+	db := txDB(t, "with_tx")
+	defer db.Close()
+	tm := NewTxManager(db)
+	repo := RepositoryImple{DB: db}
+
+	err := tm.WithTx(context.Background(), func(ctx context.Context) error {
+		return repo.Conn(ctx).Exec("UPDATE students SET name = 'a'").Error
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"BEGIN", "UPDATE students SET name = 'a'", "COMMIT"}, executed("with_tx"))
+
+	failed := errors.New("failed")
+	err = tm.WithTx(context.Background(), func(ctx context.Context) error {
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, executed("with_tx"))
+
+	assert.Panics(t, func() {
+		tm.WithTx(context.Background(), func(ctx context.Context) error {
+			panic("panic")
+		})
+	})
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, executed("with_tx"))
+}
+
+func TestWithTxSavepoint(t *testing.T) {
+	db := txDB(t, "savepoint")
+	defer db.Close()
+	tm := NewTxManager(db)
+
+	err := tm.WithTx(context.Background(), func(ctx context.Context) error {
+		inner := tm.WithTx(ctx, func(ctx context.Context) error {
+			return errors.New("failed")
+		})
+		assert.NotNil(t, inner)
+		return tm.WithTx(ctx, func(ctx context.Context) error {
+			return nil
+		})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"SAVEPOINT bdx_sp_1",
+		"ROLLBACK TO SAVEPOINT bdx_sp_1",
+		"SAVEPOINT bdx_sp_2",
+		"RELEASE SAVEPOINT bdx_sp_2",
+		"COMMIT",
+	}, executed("savepoint"))
+}
+
+func TestTxMiddleware(t *testing.T) {
+	db := txDB(t, "middleware")
+	defer db.Close()
+	tm := NewTxManager(db)
+	repo := RepositoryImple{DB: db}
+
+	router := bdx.New()
+	router.Use(tm.Middleware())
+	var committed []string
+	router.GET("/ok", func(c interfaces.Context) {
+		repo.Conn(c.Request().Context()).Exec("UPDATE ok")
+		c.JSON(http.StatusOK, bdx.B{})
+		committed = executed("middleware")
+	})
+	router.GET("/status", func(c interfaces.Context) {
+		repo.Conn(c.Request().Context()).Exec("UPDATE status")
+		c.JSON(http.StatusConflict, bdx.B{})
+	})
+	router.GET("/error", func(c interfaces.Context) {
+		repo.Conn(c.Request().Context()).Exec("UPDATE error")
+		c.SetError(errors.New("failed"))
+		c.JSON(http.StatusOK, bdx.B{})
+	})
+	router.GET("/panic", func(c interfaces.Context) {
+		panic("panic")
+	})
+
+	serve := func(path string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	serve("/ok")
+	// レスポンスを書き込む前にコミットする
+	assert.Equal(t, []string{"BEGIN", "UPDATE ok", "COMMIT"}, committed)
+	serve("/status")
+	assert.Equal(t, []string{"BEGIN", "UPDATE status", "ROLLBACK"}, executed("middleware"))
+	serve("/error")
+	assert.Equal(t, []string{"BEGIN", "UPDATE error", "ROLLBACK"}, executed("middleware"))
+	assert.Panics(t, func() {
+		serve("/panic")
+	})
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, executed("middleware"))
+}
+
+func TestTxMiddlewareCommitError(t *testing.T) {
+	db := txDB(t, "commit_fail")
+	defer db.Close()
+	setCommitFail("commit_fail", true)
+	defer setCommitFail("commit_fail", false)
+	tm := NewTxManager(db)
+	repo := RepositoryImple{DB: db}
+
+	var err error
+	router := bdx.New()
+	router.Use(func(c interfaces.Context) {
+		c.Next()
+		err = c.Error()
+	})
+	router.Use(tm.Middleware())
+	router.GET("/written", func(c interfaces.Context) {
+		repo.Conn(c.Request().Context()).Exec("UPDATE written")
+		c.JSON(http.StatusOK, bdx.B{})
+	})
+	router.GET("/empty", func(c interfaces.Context) {
+		repo.Conn(c.Request().Context()).Exec("UPDATE empty")
+	})
+
+	// ハンドラのレスポンスは破棄して500を返す
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/written", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.EqualError(t, err, "commit failed")
+	assert.Equal(t, []string{"BEGIN", "UPDATE written", "COMMIT"}, executed("commit_fail"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/empty", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.EqualError(t, err, "commit failed")
+	assert.Equal(t, []string{"BEGIN", "UPDATE empty", "COMMIT"}, executed("commit_fail"))
+}
+
+func TestRepositoryConn(t *testing.T) {
+	db := fakeDB(t, "", "conn_replica")
+	defer db.Close()
+	repo := RepositoryImple{DB: db}
+	assert.Equal(t, db.Writer().DB(), repo.Conn(context.Background()).CommonDB().(*ctxConn).conn)
+	assert.Equal(t, db.replicas[0].db.DB(), repo.ReadConn(context.Background()).CommonDB().(*ctxConn).conn)
+}
@@ -1,11 +1,17 @@
 package interfaces
 
 import (
+	"io"
+	"mime/multipart"
 	"net/http"
+	"time"
 
 	logger "github.com/belldata-dx/bdx-logger"
+	"github.com/belldata-dx/bdx/cookie"
 	"github.com/belldata-dx/bdx/param"
 	"github.com/belldata-dx/bdx/render"
+	"github.com/belldata-dx/bdx/util/conv/datetimeconv"
+	"github.com/belldata-dx/bdx/websocket"
 )
 
 type (
@@ -24,6 +30,7 @@ type (
 		PUT(path string, handlers ...BdxHandlerFunc) Routes
 		DELETE(path string, handlers ...BdxHandlerFunc) Routes
 		OPTIONS(path string, handlers ...BdxHandlerFunc) Routes
+		WS(path string, handler WSHandler, handlers ...BdxHandlerFunc) Routes
 		Use(middleware ...BdxHandlerFunc) Routes
 	}
 
@@ -49,10 +56,44 @@ type (
 		AbortWithStatusAndMessage(status int, buf []byte)
 		// AbortWithUnsupportedMediaType 後続を処理せず`この処理`で終了し、エラーレスポンスを生成
 		AbortWithUnsupportedMediaType()
+		// SetError ハンドラで発生したエラーを記録
+		SetError(err error)
+		// Error 記録されたエラー
+		Error() error
+		// BeforeWrite レスポンスヘッダーを書き込む直前に実行する処理を追加します。
+		BeforeWrite(fn func())
+		// SetResponseStatus `BeforeWrite`の処理内で、書き込むHTTP response codeを変更します。
+		SetResponseStatus(code int)
+		// ResponseStatus 書き込まれたHTTP response code(未書き込みの場合は0)
+		ResponseStatus() int
+		// JSONCodec `Engine`に設定されたJSONの実装
+		JSONCodec() render.JSONCodec
+		// Cookie リクエストのCookieの値を返します。存在しない場合は`http.ErrNoCookie`を返します。
+		Cookie(name string) (string, error)
+		// SetCookie `Engine.CookieConfig()`の属性でCookieを設定します。
+		SetCookie(name, value string, maxAge int)
+		// SignedCookie `SetSignedCookie`で設定したCookieを検証して値を返します。
+		SignedCookie(name string) (string, error)
+		// SetSignedCookie 署名付きのCookieを設定します。
+		SetSignedCookie(name, value string, maxAge int) error
+		// EncryptedCookie `SetEncryptedCookie`で設定したCookieを復号して値を返します。
+		EncryptedCookie(name string) (string, error)
+		// SetEncryptedCookie 暗号化したCookieを設定します。
+		SetEncryptedCookie(name, value string, maxAge int) error
 		// Params URIパス パラメータ
 		Params() param.Params
 		// SetParams paramsをセット
 		SetParams(params *param.Params)
+		// ParamInt パスパラメータを`int`で返します。
+		ParamInt(name string) (int, error)
+		// ParamInt64 パスパラメータを`int64`で返します。
+		ParamInt64(name string) (int64, error)
+		// ParamUUID パスパラメータをUUIDとして検証し、小文字に正規化して返します。
+		ParamUUID(name string) (string, error)
+		// ParamBool パスパラメータを`bool`で返します。
+		ParamBool(name string) (bool, error)
+		// ParamTime パスパラメータを`layout`の書式で`time.Time`に変換して返します。
+		ParamTime(name string, layout datetimeconv.DateTimeFormat) (time.Time, error)
 		// SetHandler ハンドラー設定
 		SetHandler(handlers HandlersChain)
 		// Handler 実際に登録されているハンドラ
@@ -63,10 +104,69 @@ type (
 		Render(code int, r render.Render)
 		// JSON JSONでHTTP responseを書き込み
 		JSON(code int, data interface{})
+		// IndentedJSON インデントしたJSONでHTTP responseを書き込み
+		IndentedJSON(code int, data interface{})
+		// SecureJSON JSONハイジャッキング対策の接頭辞を付けたJSONでHTTP responseを書き込み
+		SecureJSON(code int, data interface{})
+		// JSONP クエリパラメータ`callback`の関数呼び出しでHTTP responseを書き込み
+		JSONP(code int, data interface{})
+		// PureJSON `<`、`>`、`&`をエスケープしないJSONでHTTP responseを書き込み
+		PureJSON(code int, data interface{})
+		// Paginated ページング付きのJSONでHTTP responseを書き込み
+		Paginated(code int, p render.Paginated)
+		// HTML `Engine`に登録されたテンプレートでHTTP responseを書き込み
+		HTML(code int, name string, data interface{})
 		// XML XMLでHTTP responseを書き込み
 		XML(code int, data interface{})
 		// YAML YAMLでHTTP responseを書き込み
 		YAML(code int, data interface{})
+		// String `format`に`values`を埋め込んだ文字列をHTTP responseとして書き込み
+		String(code int, format string, values ...interface{})
+		// Data `contentType`で`data`をHTTP responseとして書き込み
+		Data(code int, contentType string, data []byte)
+		// Redirect `location`へリダイレクトします。
+		Redirect(code int, location string)
+		// NoContent 本文の無いHTTP responseを書き込み
+		NoContent(code int)
+		// ProtoBuf Protocol BuffersでHTTP responseを書き込み
+		ProtoBuf(code int, data interface{})
+		// MsgPack MessagePackでHTTP responseを書き込み
+		MsgPack(code int, data interface{})
+		// File `filepath`のファイルをHTTP responseとして書き込み
+		File(filepath string)
+		// FileAttachment `filepath`のファイルを`filename`のファイル名でダウンロードさせます。
+		FileAttachment(filepath, filename string)
+		// DataFromReader `reader`の内容をHTTP responseとして書き込み
+		DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string)
+		// MultipartForm マルチパートフォームを解析して返します。
+		MultipartForm() (*multipart.Form, error)
+		// FormFile マルチパートフォームの`name`の最初のファイルを返します。
+		FormFile(name string) (*multipart.FileHeader, error)
+		// SaveUploadedFile アップロードされたファイルを`dst`に保存します。
+		SaveUploadedFile(file *multipart.FileHeader, dst string) error
+		// NDJSON `rows`の各行を改行区切りのJSONで順次書き込み
+		NDJSON(code int, rows render.Rows)
+		// JSONArray `rows`の各行をJSON配列の要素として順次書き込み
+		JSONArray(code int, rows render.Rows)
+		// CSV `header`と`rows`の各行をCSVで順次書き込み
+		CSV(code int, header []string, rows render.Rows)
+		// RenderStream `Render`と同じですが、書き込み中のエラーはpanicせずに記録します。
+		RenderStream(code int, r render.Render)
+		// SSEvent Server-Sent Eventsのイベントを書き込み、フラッシュします。
+		SSEvent(event string, data interface{})
+		// SSE `render.SSE`のイベントを書き込み、フラッシュします。
+		SSE(e render.SSE)
+		// Stream `step`が`false`を返すかクライアントが切断するまで`step`を繰り返し実行します。
+		// クライアントが切断した場合は`true`を返します。
+		Stream(step func(w io.Writer) bool) bool
+		// LastEventID 再接続したクライアントが最後に受信したイベントのID
+		LastEventID() string
+		// Bind リクエストボディを`Content-Type`に応じてデコードし、`obj`に設定します。
+		Bind(obj interface{}) error
+		// NegotiateFormat `offered`の中から`Accept`ヘッダーで最も優先される形式を返します。
+		NegotiateFormat(offered ...string) string
+		// Negotiate `Accept`ヘッダーで選択した形式でHTTP responseを書き込み
+		Negotiate(code int, n render.Negotiation)
 		// Query url parameterが存在すればそれを返します。
 		// 存在しない場合は空文字を返します。
 		// これは`c.Request.URL.Query().Get(key)`のショートカットと同じです。
@@ -125,15 +225,29 @@ type (
 	Engine interface {
 		Routes
 		MaxMultipartMemory() int64
+		MaxUploadSize() int64
+		CookieConfig() cookie.Config
+		HTMLTemplates() *render.HTMLTemplates
+		NegotiateDefault() string
+		SSEHeartbeat() time.Duration
+		JSONCodec() render.JSONCodec
 	}
 
 	// BdxHandlerFunc ハンドラ
 	BdxHandlerFunc func(Context)
 
+	// WSHandler WebSocketへアップグレードした後に実行するハンドラ
+	WSHandler func(c Context, conn *websocket.Conn)
+
 	// HandlersChain ハンドラチェーン
 	HandlersChain []BdxHandlerFunc
 )
 
+type contextKey struct{}
+
+// ContextKey `request.Context()`から`Context`を取得するコンテキストキー
+var ContextKey = contextKey{}
+
 // Last returns the last handler in the chain. ie. the last handler is the main one.
 func (c HandlersChain) Last() BdxHandlerFunc {
 	if length := len(c); length > 0 {
@@ -0,0 +1,25 @@
+// Package sqlitetest SQLiteに接続するテストの共通処理
+package sqlitetest
+
+import (
+	"database/sql"
+	"testing"
+
+	// SQLiteのドライバを登録する
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Skip SQLiteを利用できない場合はテストをスキップします。
+//
+// `github.com/mattn/go-sqlite3`はcgoが必要なため、cgoが無効な場合はドライバの使用時にエラーになります。
+func Skip(t *testing.T) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err == nil {
+		err = db.Ping()
+		db.Close()
+	}
+	if err != nil {
+		t.Skipf("SQLiteを利用できません: %v", err)
+	}
+}
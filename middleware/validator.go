@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"bytes"
-	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"io/ioutil"
@@ -10,12 +9,15 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/belldata-dx/bdx/binding"
 	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/render"
 	"github.com/go-playground/locales"
 	"github.com/go-playground/locales/ja"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	jaTranslations "github.com/go-playground/validator/v10/translations/ja"
+	"github.com/ugorji/go/codec"
 	"gopkg.in/yaml.v2"
 )
 
@@ -57,6 +59,8 @@ const (
 	MIMEMultipartPOSTForm = "multipart/form-data"
 	MIMEPROTOBUF          = "application/x-protobuf"
 	MIMEYAML              = "application/x-yaml"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
 )
 
 type errorResponse struct {
@@ -73,11 +77,15 @@ const (
 	XML
 	YAML
 	HTML
+	PROTOBUF
+	MSGPACK
 )
 
+// checkAccept `Accept`ヘッダーのq値を考慮してエラーレスポンスの形式を決定します。
+// 一致する形式が無い場合はJSONを返します。
 func checkAccept(r *http.Request) MIMEType {
 	accept := r.Header.Get("Accept")
-	m, _ := checkContent(accept)
+	m, _ := checkContent(render.NegotiateFormat(accept, MIMEJSON, MIMEXML, MIMEXML2, MIMEYAML, MIMEMSGPACK, MIMEMSGPACK2))
 	return m
 }
 
@@ -95,15 +103,19 @@ func checkContent(content string) (MIMEType, bool) {
 		return XML, true
 	} else if strings.HasPrefix(content, MIMEYAML) {
 		return YAML, true
+	} else if strings.HasPrefix(content, MIMEPROTOBUF) {
+		return PROTOBUF, true
+	} else if strings.HasPrefix(content, MIMEMSGPACK) || strings.HasPrefix(content, MIMEMSGPACK2) {
+		return MSGPACK, true
 	} else {
 		return JSON, false
 	}
 }
 
-func convResBody(m MIMEType, data interface{}) []byte {
+func convResBody(jsonCodec render.JSONCodec, m MIMEType, data interface{}) []byte {
 	switch m {
 	case JSON:
-		buf, _ := json.Marshal(&data)
+		buf, _ := jsonCodec.Marshal(&data)
 		return buf
 	case XML:
 		buf, _ := xml.Marshal(&data)
@@ -111,25 +123,41 @@ func convResBody(m MIMEType, data interface{}) []byte {
 	case YAML:
 		buf, _ := yaml.Marshal(&data)
 		return buf
+	case MSGPACK:
+		var buf []byte
+		codec.NewEncoderBytes(&buf, render.MsgPackHandle).Encode(&data)
+		return buf
 	default:
-		buf, _ := json.Marshal(&data)
+		buf, _ := jsonCodec.Marshal(&data)
 		return buf
 	}
 }
 
-func convReqBody(m MIMEType, data []byte, instance interface{}) error {
+func convReqBody(jsonCodec render.JSONCodec, m MIMEType, data []byte, instance interface{}) error {
 	switch m {
 	case JSON:
-		return json.Unmarshal(data, instance)
+		return binding.JSONWith(jsonCodec).Decode(data, instance)
 	case XML:
 		return xml.Unmarshal(data, instance)
 	case YAML:
 		return yaml.Unmarshal(data, instance)
+	case PROTOBUF:
+		return binding.ProtoBuf.Decode(data, unwrap(instance))
+	case MSGPACK:
+		return binding.MsgPack.Decode(data, unwrap(instance))
 	default:
 		return errors.New("no content")
 	}
 }
 
+// unwrap `*interface{}`に格納されたデコード先のポインタを取り出します。
+func unwrap(instance interface{}) interface{} {
+	if p, ok := instance.(*interface{}); ok {
+		return *p
+	}
+	return instance
+}
+
 // Validator は`Request.Body`にセットされている`JSON文字列`を`x`の引数の型に変換し、
 //
 // `go-playground.Validator`でバリデーション処理を行うミドルウェア
@@ -151,7 +179,7 @@ func Validator(x interface{}) interfaces.BdxHandlerFunc {
 					ErrorDescript: "Invalid Content-Type",
 					ErrorDetail:   nil,
 				}
-				buf := convResBody(checkAccept(r), errorBody)
+				buf := convResBody(ctx.JSONCodec(), checkAccept(r), errorBody)
 				ctx.AbortWithStatusAndMessage(http.StatusBadRequest, buf)
 				ctx.Next()
 			} else {
@@ -163,12 +191,12 @@ func Validator(x interface{}) interfaces.BdxHandlerFunc {
 						ErrorDescript: "Invalid body parser",
 						ErrorDetail:   nil,
 					}
-					buf := convResBody(checkAccept(r), errorBody)
+					buf := convResBody(ctx.JSONCodec(), checkAccept(r), errorBody)
 					ctx.AbortWithStatusAndMessage(http.StatusBadRequest, buf)
 				} else {
 					r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 					instance := reflect.New(t).Interface()
-					convReqBody(mimeType, body, &instance)
+					convReqBody(ctx.JSONCodec(), mimeType, body, &instance)
 					err = validate.Struct(instance)
 					if err != nil {
 						messages := err.(validator.ValidationErrors).Translate(trans)
@@ -183,7 +211,7 @@ func Validator(x interface{}) interfaces.BdxHandlerFunc {
 							ErrorDescript: "Invalid body parser",
 							ErrorDetail:   errMsgMap,
 						}
-						buf := convResBody(checkAccept(r), errorBody)
+						buf := convResBody(ctx.JSONCodec(), checkAccept(r), errorBody)
 						ctx.AbortWithStatusAndMessage(http.StatusBadRequest, buf)
 					}
 				}
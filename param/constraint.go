@@ -0,0 +1,100 @@
+package param
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// Constraint ルートパラメータの制約
+//
+// `/students/:id<int>`のように指定したパスパラメータの値を検証します。
+type Constraint func(value string) bool
+
+var errInvalidUUID = errors.New("UUIDの形式ではありません。")
+
+var (
+	constraintsMu sync.RWMutex
+	constraints   = map[string]Constraint{
+		"int": func(v string) bool {
+			_, err := strconv.Atoi(v)
+			return err == nil
+		},
+		"int64": func(v string) bool {
+			_, err := strconv.ParseInt(v, 10, 64)
+			return err == nil
+		},
+		"uint": func(v string) bool {
+			_, err := strconv.ParseUint(v, 10, 64)
+			return err == nil
+		},
+		"bool": func(v string) bool {
+			_, err := strconv.ParseBool(v)
+			return err == nil
+		},
+		"uuid": isUUID,
+		"alpha": func(v string) bool {
+			return v != "" && every(v, isAlpha)
+		},
+		"alnum": func(v string) bool {
+			return v != "" && every(v, func(r byte) bool { return isAlpha(r) || isDigit(r) })
+		},
+	}
+)
+
+// RegisterConstraint ルートパラメータの制約を登録します。
+//     param.RegisterConstraint("code", func(v string) bool { return len(v) == 3 })
+//     router.GET("/countries/:code<code>", handler)
+func RegisterConstraint(name string, c Constraint) {
+	constraintsMu.Lock()
+	defer constraintsMu.Unlock()
+	constraints[name] = c
+}
+
+// LookupConstraint 登録されている制約を返します。
+func LookupConstraint(name string) (Constraint, bool) {
+	constraintsMu.RLock()
+	defer constraintsMu.RUnlock()
+	c, ok := constraints[name]
+	return c, ok
+}
+
+func isUUID(v string) bool {
+	if len(v) != 36 {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if v[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(v[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func every(v string, fn func(byte) bool) bool {
+	for i := 0; i < len(v); i++ {
+		if !fn(v[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(r byte) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+}
+
+func isDigit(r byte) bool {
+	return '0' <= r && r <= '9'
+}
+
+func isHex(r byte) bool {
+	return isDigit(r) || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F'
+}
@@ -1,5 +1,15 @@
 package param
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/belldata-dx/bdx/util/conv/datetimeconv"
+)
+
 type (
 	// Param path parameter
 	Param struct {
@@ -24,3 +34,103 @@ func (ps Params) ByName(name string) string {
 	}
 	return ""
 }
+
+// ErrNotFound パスパラメータが存在しない
+var ErrNotFound = errors.New("パスパラメータが存在しません。")
+
+// ParseError パスパラメータの変換エラー
+type ParseError struct {
+	Key   string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	if e.Err == ErrNotFound {
+		return fmt.Sprintf("パスパラメータ %s が存在しません。", e.Key)
+	}
+	return fmt.Sprintf("パスパラメータ %s=%q を%sに変換できません: %v", e.Key, e.Value, e.Type, e.Err)
+}
+
+// Unwrap 変換元のエラー
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func (ps Params) get(name, typ string) (string, error) {
+	for i := range ps {
+		if ps[i].Key == name {
+			return ps[i].Value, nil
+		}
+	}
+	return "", &ParseError{Key: name, Type: typ, Err: ErrNotFound}
+}
+
+// Int パスパラメータを`int`に変換します。
+func (ps Params) Int(name string) (int, error) {
+	val, err := ps.get(name, "int")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, &ParseError{Key: name, Value: val, Type: "int", Err: err}
+	}
+	return i, nil
+}
+
+// Int64 パスパラメータを`int64`に変換します。
+func (ps Params) Int64(name string) (int64, error) {
+	val, err := ps.get(name, "int64")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, &ParseError{Key: name, Value: val, Type: "int64", Err: err}
+	}
+	return i, nil
+}
+
+// UUID パスパラメータをUUIDとして検証し、小文字に正規化して返します。
+//     /students/:id  GET /students/0E8E3B1A-5F4C-4C1B-9D7A-2B2E1C3D4F5A
+//     pm.UUID("id") == "0e8e3b1a-5f4c-4c1b-9d7a-2b2e1c3d4f5a"
+func (ps Params) UUID(name string) (string, error) {
+	val, err := ps.get(name, "uuid")
+	if err != nil {
+		return "", err
+	}
+	if !isUUID(val) {
+		return "", &ParseError{Key: name, Value: val, Type: "uuid", Err: errInvalidUUID}
+	}
+	return strings.ToLower(val), nil
+}
+
+// Bool パスパラメータを`bool`に変換します。(`1`, `t`, `true`, `0`, `f`, `false`など)
+func (ps Params) Bool(name string) (bool, error) {
+	val, err := ps.get(name, "bool")
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, &ParseError{Key: name, Value: val, Type: "bool", Err: err}
+	}
+	return b, nil
+}
+
+// Time パスパラメータを`layout`の書式で`time.Time`に変換します。
+//     /reports/:date  GET /reports/2020-08-01
+//     pm.Time("date", datetimeconv.YYYYMMDDHyphen)
+func (ps Params) Time(name string, layout datetimeconv.DateTimeFormat) (time.Time, error) {
+	val, err := ps.get(name, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := datetimeconv.StringToDateTime(&layout, val)
+	if t == nil {
+		return time.Time{}, &ParseError{Key: name, Value: val, Type: "time", Err: fmt.Errorf("書式 %s と一致しません。", layout)}
+	}
+	return *t, nil
+}
@@ -1,9 +1,14 @@
 package param_test
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/belldata-dx/bdx/param"
+	"github.com/belldata-dx/bdx/util/conv/datetimeconv"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +22,67 @@ func TestParams(t *testing.T) {
 	id := pm.ByName("id")
 	assert.Equal(t, id, "1")
 }
+
+func TestTypedParams(t *testing.T) {
+	pm := param.Params{
+		{Key: "id", Value: "42"},
+		{Key: "big", Value: "9223372036854775807"},
+		{Key: "uuid", Value: "0E8E3B1A-5F4C-4C1B-9D7A-2B2E1C3D4F5A"},
+		{Key: "flag", Value: "true"},
+		{Key: "date", Value: "2020-08-01"},
+		{Key: "name", Value: "abc"},
+	}
+	id, err := pm.Int("id")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, id)
+	big, err := pm.Int64("big")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(math.MaxInt64), big)
+	uuid, err := pm.UUID("uuid")
+	assert.Nil(t, err)
+	assert.Equal(t, "0e8e3b1a-5f4c-4c1b-9d7a-2b2e1c3d4f5a", uuid)
+	flag, err := pm.Bool("flag")
+	assert.Nil(t, err)
+	assert.True(t, flag)
+	date, err := pm.Time("date", datetimeconv.YYYYMMDDHyphen)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC), date)
+
+	_, err = pm.Int("name")
+	var perr *param.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "name", perr.Key)
+	assert.Equal(t, "int", perr.Type)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+	_, err = pm.UUID("name")
+	assert.NotNil(t, err)
+	_, err = pm.Bool("name")
+	assert.NotNil(t, err)
+	_, err = pm.Time("name", datetimeconv.YYYYMMDDHyphen)
+	assert.Equal(t, `パスパラメータ name="abc" をtimeに変換できません: 書式 2006-01-02 と一致しません。`, err.Error())
+	_, err = pm.Int64("missing")
+	assert.True(t, errors.Is(err, param.ErrNotFound))
+}
+
+func TestConstraint(t *testing.T) {
+	for name, cases := range map[string]map[string]bool{
+		"int":   {"1": true, "-1": true, "a": false, "": false},
+		"uint":  {"1": true, "-1": false},
+		"uuid":  {"0e8e3b1a-5f4c-4c1b-9d7a-2b2e1c3d4f5a": true, "0e8e3b1a5f4c4c1b9d7a2b2e1c3d4f5a": false},
+		"bool":  {"true": true, "yes": false},
+		"alpha": {"abc": true, "ab1": false},
+		"alnum": {"ab1": true, "ab-1": false},
+	} {
+		c, ok := param.LookupConstraint(name)
+		assert.True(t, ok, name)
+		for v, expected := range cases {
+			assert.Equal(t, expected, c(v), name+":"+v)
+		}
+	}
+	param.RegisterConstraint("code", func(v string) bool { return len(v) == 3 })
+	c, ok := param.LookupConstraint("code")
+	assert.True(t, ok)
+	assert.True(t, c("JPN"))
+	_, ok = param.LookupConstraint("unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type (
+	// JSONCodec JSONのエンコード・デコードの実装
+	// `encoding/json`と互換性のある高速なライブラリに差し替えることができます。
+	//     var jsoniterCodec = jsoniter.ConfigCompatibleWithStandardLibrary
+	//
+	//     type codec struct{}
+	//
+	//     func (codec) Marshal(v interface{}) ([]byte, error) { return jsoniterCodec.Marshal(v) }
+	//     ...
+	//     router.SetJSONCodec(codec{})
+	JSONCodec interface {
+		Marshal(v interface{}) ([]byte, error)
+		MarshalIndent(v interface{}, prefix, indent string) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+		NewEncoder(w io.Writer) JSONEncoder
+	}
+
+	// JSONEncoder `JSONCodec.NewEncoder`が返すエンコーダ
+	JSONEncoder interface {
+		SetEscapeHTML(on bool)
+		SetIndent(prefix, indent string)
+		Encode(v interface{}) error
+	}
+
+	// StdJSONCodec `encoding/json`の`JSONCodec`
+	StdJSONCodec struct{}
+)
+
+// codecOf `codec`が`nil`の場合は`StdJSONCodec`を返します。
+func codecOf(codec JSONCodec) JSONCodec {
+	if codec == nil {
+		return StdJSONCodec{}
+	}
+	return codec
+}
+
+// Marshal `json.Marshal`
+func (StdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MarshalIndent `json.MarshalIndent`
+func (StdJSONCodec) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return json.MarshalIndent(v, prefix, indent)
+}
+
+// Unmarshal `json.Unmarshal`
+func (StdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// NewEncoder `json.NewEncoder`
+func (StdJSONCodec) NewEncoder(w io.Writer) JSONEncoder {
+	return json.NewEncoder(w)
+}
@@ -0,0 +1,38 @@
+package render_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+// upperCodec 呼び出されたことが分かるように出力を大文字にする`JSONCodec`
+type upperCodec struct {
+	render.StdJSONCodec
+}
+
+func (c upperCodec) Marshal(v interface{}) ([]byte, error) {
+	buf, err := c.StdJSONCodec.Marshal(v)
+	return bytes.ToUpper(buf), err
+}
+
+func TestJSONCodec(t *testing.T) {
+	w := httptest.NewRecorder()
+	render.JSON{Data: map[string]string{"name": "taro"}, Codec: upperCodec{}}.Render(w)
+	assert.Equal(t, `{"NAME":"TARO"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.SecureJSON{Data: []string{"a"}, Codec: upperCodec{}}.Render(w))
+	assert.Equal(t, `while(1);["A"]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.Paginated{Data: []string{"a"}, Page: 1, PerPage: 1, Total: 1, Codec: upperCodec{}}.Render(w))
+	assert.Equal(t, `{"DATA":["A"],"PAGINATION":{"PAGE":1,"PER_PAGE":1,"TOTAL":1,"TOTAL_PAGES":1}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	render.JSON{Data: map[string]string{"name": "taro"}}.Render(w)
+	assert.Equal(t, `{"name":"taro"}`, w.Body.String())
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CSV 1行ずつCSVで書き込みます。
+// `Rows`が返す行は`[]string`または`[]interface{}`(各値を`fmt.Sprint`で変換)である必要があります。
+//     c.Render(http.StatusOK, render.CSV{
+//         Context: c.Request().Context(),
+//         Header:  []string{"ID", "氏名"},
+//         Rows:    render.FromChan(ch),
+//         Comma:   ';',
+//         BOM:     true, // Excelで文字化けしないようにする
+//     })
+type CSV struct {
+	Context       context.Context
+	Header        []string
+	Rows          Rows
+	Comma         rune
+	UseCRLF       bool
+	BOM           bool
+	FlushRows     int
+	FlushInterval time.Duration
+}
+
+var (
+	csvContentType = []string{"text/csv; charset=utf-8"}
+	utf8BOM        = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// ErrInvalidCSVRow `CSV`の行が`[]string`または`[]interface{}`ではない
+var ErrInvalidCSVRow = errors.New("render: CSVの行は[]stringまたは[]interface{}である必要があります")
+
+// Render `Header`と`Rows`の各行をCSVで書き込みます。
+func (r CSV) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if r.BOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+	cw := csv.NewWriter(w)
+	if r.Comma != 0 {
+		cw.Comma = r.Comma
+	}
+	cw.UseCRLF = r.UseCRLF
+	if r.Header != nil {
+		if err := cw.Write(r.Header); err != nil {
+			return err
+		}
+	}
+	f := newFlusher(w, r.FlushRows, r.FlushInterval)
+	// `csv.Writer`はバッファリングするため、先に書き出してからフラッシュする
+	f.before = func() error {
+		cw.Flush()
+		return cw.Error()
+	}
+	return stream(r.Context, r.Rows, f, func(row interface{}) error {
+		record, err := csvRecord(row)
+		if err != nil {
+			return err
+		}
+		return cw.Write(record)
+	}, nil)
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r CSV) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, csvContentType)
+}
+
+func csvRecord(row interface{}) ([]string, error) {
+	switch v := row.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		record := make([]string, len(v))
+		for i, val := range v {
+			if val != nil {
+				record[i] = fmt.Sprint(val)
+			}
+		}
+		return record, nil
+	default:
+		return nil, ErrInvalidCSVRow
+	}
+}
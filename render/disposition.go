@@ -0,0 +1,69 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentDisposition RFC 6266の`Content-Disposition`ヘッダーの値を返します。
+// `filename`がASCII以外の文字を含む場合は、RFC 5987でエンコードした`filename*`と、
+// 非対応のクライアント向けにASCII以外を`_`に置き換えた`filename`を併記します。
+//     ContentDisposition("attachment", "名簿.csv")
+//     // attachment; filename="__.csv"; filename*=UTF-8''%E5%90%8D%E7%B0%BF.csv
+func ContentDisposition(disposition, filename string) string {
+	if filename == "" {
+		return disposition
+	}
+	fallback, ascii := asciiFilename(filename)
+	value := disposition + `; filename="` + fallback + `"`
+	if !ascii {
+		value += `; filename*=UTF-8''` + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// asciiFilename `filename`の制御文字とASCII以外の文字を`_`に置き換え、`"`と`\`をエスケープします。
+func asciiFilename(filename string) (string, bool) {
+	var b strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r >= utf8.RuneSelf:
+			ascii = false
+			b.WriteByte('_')
+		case r < 0x20 || r == 0x7f:
+			b.WriteByte('_')
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), ascii
+}
+
+// encodeRFC5987 attr-char以外のバイトをパーセントエンコードします。
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
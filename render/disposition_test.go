@@ -0,0 +1,16 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `attachment; filename="report.csv"`, render.ContentDisposition("attachment", "report.csv"))
+	assert.Equal(t, `attachment; filename="__.csv"; filename*=UTF-8''%E5%90%8D%E7%B0%BF.csv`, render.ContentDisposition("attachment", "名簿.csv"))
+	assert.Equal(t, `inline; filename="a \"b\" \\c.txt"`, render.ContentDisposition("inline", `a "b" \c.txt`))
+	assert.Equal(t, `attachment; filename="a_b _.txt"; filename*=UTF-8''a%0Ab%20%C3%A9.txt`, render.ContentDisposition("attachment", "a\nb é.txt"))
+	assert.Equal(t, "attachment", render.ContentDisposition("attachment", ""))
+}
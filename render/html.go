@@ -0,0 +1,241 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var htmlContentType = []string{"text/html; charset=utf-8"}
+
+// ContentBlock レイアウトへ埋め込むページのテンプレート名
+const ContentBlock = "content"
+
+type (
+	// HTML テンプレートを実行してHTMLを書き込みます。
+	HTML struct {
+		Template *template.Template
+		Name     string
+		Data     interface{}
+	}
+
+	// HTMLTemplates Engineに登録するHTMLテンプレート
+	//
+	// `SharedDirs`のファイル(レイアウト、部分テンプレート)は全てのページから参照できます。
+	// それ以外のファイルはページとして個別に読み込み、ファイルのパスで`Context.HTML`から指定します。
+	// `Layout`を指定した場合、`{{define "content"}}`を持つページはレイアウトの`{{template "content" .}}`に埋め込まれます。
+	//     templates/layouts/base.html     <html><body>{{template "content" .}}</body></html>
+	//     templates/partials/header.html  <h1>{{.Title}}</h1>
+	//     templates/students/index.html   {{define "content"}}{{template "partials/header.html" .}}...{{end}}
+	//
+	//     t := render.GlobTemplates("templates/*/*.html")
+	//     t.Layout = "layouts/base.html"
+	//     router.SetHTMLTemplates(t)
+	//     c.HTML(200, "students/index.html", data)
+	HTMLTemplates struct {
+		// FuncMap テンプレートで使用する関数
+		FuncMap template.FuncMap
+		// Layout ページを埋め込むレイアウトのテンプレート名
+		Layout string
+		// SharedDirs 全てのページから参照できるテンプレートのディレクトリ(デフォルト`layouts`、`partials`)
+		SharedDirs []string
+		// Debug リクエスト毎にテンプレートを読み込み直す
+		Debug bool
+
+		files func() (map[string]string, error)
+		mu    sync.RWMutex
+		pages map[string]*page
+	}
+
+	page struct {
+		template *template.Template
+		layout   bool
+	}
+)
+
+// Render 与えられたインターフェースオブジェクトをマーシャルし、カスタムContentTypeでデータを書き込みます(HTML)
+func (r HTML) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if r.Name == "" {
+		return r.Template.Execute(w, r.Data)
+	}
+	return r.Template.ExecuteTemplate(w, r.Name, r.Data)
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r HTML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
+
+// GlobTemplates `pattern`に一致するファイルを読み込みます。
+// テンプレート名は`pattern`のワイルドカードより前のディレクトリからの相対パスになります。
+func GlobTemplates(pattern string) *HTMLTemplates {
+	base := pattern
+	if i := strings.IndexAny(base, "*?["); i >= 0 {
+		base = base[:i]
+	}
+	base = filepath.Dir(base + "x")
+	return &HTMLTemplates{files: func() (map[string]string, error) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files := map[string]string{}
+		for _, file := range matches {
+			buf, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			name, err := filepath.Rel(base, file)
+			if err != nil {
+				return nil, err
+			}
+			files[filepath.ToSlash(name)] = string(buf)
+		}
+		return files, nil
+	}}
+}
+
+// FSTemplates `fs`の`root`以下にある`.html`、`.tmpl`ファイルを読み込みます。
+// テンプレート名は`root`からの相対パスになります。
+//     render.FSTemplates(http.Dir("templates"), "/")
+func FSTemplates(fs http.FileSystem, root string) *HTMLTemplates {
+	return &HTMLTemplates{files: func() (map[string]string, error) {
+		files := map[string]string{}
+		return files, walkFS(fs, path.Clean("/"+root), "", files)
+	}}
+}
+
+func walkFS(fs http.FileSystem, dir, prefix string, files map[string]string) error {
+	f, err := fs.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := path.Join(prefix, info.Name())
+		full := path.Join(dir, info.Name())
+		if info.IsDir() {
+			if err := walkFS(fs, full, name, files); err != nil {
+				return err
+			}
+			continue
+		}
+		if ext := path.Ext(name); ext != ".html" && ext != ".tmpl" {
+			continue
+		}
+		file, err := fs.Open(full)
+		if err != nil {
+			return err
+		}
+		buf, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		files[name] = string(buf)
+	}
+	return nil
+}
+
+func (t *HTMLTemplates) shared(name string) bool {
+	dirs := t.SharedDirs
+	if len(dirs) == 0 {
+		dirs = []string{"layouts", "partials"}
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(name, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *HTMLTemplates) parse() (map[string]*page, error) {
+	if t.files == nil {
+		return nil, fmt.Errorf("テンプレートの読み込み元が指定されていません。")
+	}
+	files, err := t.files()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	shared := template.New("").Funcs(t.FuncMap)
+	for _, name := range names {
+		if t.shared(name) {
+			if _, err := shared.New(name).Parse(files[name]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	pages := map[string]*page{}
+	for _, name := range names {
+		if t.shared(name) {
+			pages[name] = &page{template: shared}
+			continue
+		}
+		standalone, err := template.New(name).Funcs(t.FuncMap).Parse(files[name])
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := shared.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(name).Parse(files[name]); err != nil {
+			return nil, err
+		}
+		pages[name] = &page{template: tmpl, layout: standalone.Lookup(ContentBlock) != nil}
+	}
+	return pages, nil
+}
+
+// Load テンプレートを読み込みます。`Debug`の場合は`Instance`の度に読み込み直します。
+func (t *HTMLTemplates) Load() error {
+	pages, err := t.parse()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.pages = pages
+	t.mu.Unlock()
+	return nil
+}
+
+// Instance `name`のテンプレートで`data`を書き込む`HTML`を返します。
+func (t *HTMLTemplates) Instance(name string, data interface{}) (Render, error) {
+	var pages map[string]*page
+	if t.Debug {
+		var err error
+		if pages, err = t.parse(); err != nil {
+			return nil, err
+		}
+	} else {
+		t.mu.RLock()
+		pages = t.pages
+		t.mu.RUnlock()
+	}
+	p, ok := pages[name]
+	if !ok {
+		return nil, fmt.Errorf("テンプレート %q は登録されていません。", name)
+	}
+	if p.layout && t.Layout != "" {
+		return HTML{Template: p.template, Name: t.Layout, Data: data}, nil
+	}
+	return HTML{Template: p.template, Name: name, Data: data}, nil
+}
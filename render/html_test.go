@@ -0,0 +1,70 @@
+package render_test
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+type page struct {
+	Title    string
+	Students []string
+}
+
+func renderHTML(t *testing.T, templates *render.HTMLTemplates, name string, data interface{}) string {
+	r, err := templates.Instance(name, data)
+	assert.Nil(t, err)
+	w := httptest.NewRecorder()
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	return strings.TrimSpace(w.Body.String())
+}
+
+func TestHTMLTemplates(t *testing.T) {
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+	for name, templates := range map[string]*render.HTMLTemplates{
+		"glob": render.GlobTemplates("testdata/templates/*/*.html"),
+		"fs":   render.FSTemplates(http.Dir("testdata"), "templates"),
+	} {
+		templates.FuncMap = funcs
+		templates.Layout = "layouts/base.html"
+		assert.Nil(t, templates.Load(), name)
+
+		data := page{Title: "<名簿>", Students: []string{"a", "b"}}
+		assert.Equal(t, `<html><title>&lt;名簿&gt;</title><body><h1>&lt;名簿&gt;</h1>`+"\n"+`<ul><li>a</li><li>b</li></ul></body></html>`,
+			renderHTML(t, templates, "students/index.html", data), name)
+		assert.Equal(t, `<p>&lt;名簿&gt;</p>`, renderHTML(t, templates, "students/raw.html", data), name)
+		assert.Equal(t, `<h1>&lt;名簿&gt;</h1>`, renderHTML(t, templates, "partials/header.html", data), name)
+
+		_, err := templates.Instance("students/none.html", data)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestHTMLDebug(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "index.html")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("v1"), 0644))
+
+	templates := render.GlobTemplates(filepath.Join(dir, "*.html"))
+	templates.Debug = true
+	assert.Nil(t, templates.Load())
+	assert.Equal(t, "v1", renderHTML(t, templates, "index.html", nil))
+	assert.Nil(t, ioutil.WriteFile(file, []byte("v2"), 0644))
+	assert.Equal(t, "v2", renderHTML(t, templates, "index.html", nil))
+
+	templates.Debug = false
+	assert.Nil(t, templates.Load())
+	assert.Nil(t, ioutil.WriteFile(file, []byte("v3"), 0644))
+	assert.Equal(t, "v2", renderHTML(t, templates, "index.html", nil))
+}
@@ -1,19 +1,20 @@
 package render
 
 import (
-	"encoding/json"
 	"net/http"
 )
 
 type JSON struct {
 	Data interface{} `json:"data"`
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec `json:"-"`
 }
 
 var jsonContentType = []string{"application/json; charset=utf-8"}
 
 // Render 与えられたインターフェースオブジェクトをマーシャルし、カスタムContentTypeでデータを書き込みます(JSON)
 func (r JSON) Render(w http.ResponseWriter) (err error) {
-	if err = WriteJSON(w, r.Data); err != nil {
+	if err = writeJSON(w, r.Codec, r.Data); err != nil {
 		panic(err)
 	}
 	return
@@ -26,8 +27,12 @@ func (r JSON) WriteContentType(w http.ResponseWriter) {
 
 // WriteJSON marshals the given interface object and writes it with custom ContentType.
 func WriteJSON(w http.ResponseWriter, obj interface{}) error {
+	return writeJSON(w, nil, obj)
+}
+
+func writeJSON(w http.ResponseWriter, codec JSONCodec, obj interface{}) error {
 	writeContentType(w, jsonContentType)
-	jsonBytes, err := json.Marshal(obj)
+	jsonBytes, err := codecOf(codec).Marshal(obj)
 	if err != nil {
 		return err
 	}
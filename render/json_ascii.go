@@ -2,7 +2,6 @@ package render
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -11,6 +10,8 @@ import (
 
 type JSONAscii struct {
 	Data interface{} `json:"data"`
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec `json:"-"`
 }
 
 var jsonAsciiContentType = []string{"application/json"}
@@ -18,7 +19,7 @@ var jsonAsciiContentType = []string{"application/json"}
 // Render 与えられたインターフェースオブジェクトをマーシャルし、カスタムContentTypeでデータを書き込みます(JSONAscii)
 func (r JSONAscii) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-	ret, err := json.Marshal(r.Data)
+	ret, err := codecOf(r.Codec).Marshal(r.Data)
 	if err != nil {
 		return err
 	}
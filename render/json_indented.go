@@ -0,0 +1,28 @@
+package render
+
+import (
+	"net/http"
+)
+
+// IndentedJSON 人が読みやすいようにインデントしたJSONを書き込みます。
+type IndentedJSON struct {
+	Data interface{}
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec
+}
+
+// Render 与えられたインターフェースオブジェクトをインデントしてマーシャルし、カスタムContentTypeでデータを書き込みます(IndentedJSON)
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	buf, err := codecOf(r.Codec).MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
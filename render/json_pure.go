@@ -0,0 +1,26 @@
+package render
+
+import (
+	"net/http"
+)
+
+// PureJSON `<`、`>`、`&`をエスケープせずにJSONを書き込みます。
+// HTMLに埋め込まないAPIで使用してください。
+type PureJSON struct {
+	Data interface{}
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec
+}
+
+// Render 与えられたインターフェースオブジェクトをHTMLエスケープせずにマーシャルし、カスタムContentTypeでデータを書き込みます(PureJSON)
+func (r PureJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	encoder := codecOf(r.Codec).NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(r.Data)
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r PureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// DefaultSecureJSONPrefix `SecureJSON`の`Prefix`を省略した場合の接頭辞
+const DefaultSecureJSONPrefix = "while(1);"
+
+// SecureJSON JSONハイジャッキングを防ぐため、配列のJSONの先頭に`Prefix`を付けて書き込みます。
+type SecureJSON struct {
+	Prefix string
+	Data   interface{}
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec
+}
+
+// Render 与えられたインターフェースオブジェクトをマーシャルし、配列の場合は`Prefix`を付けてデータを書き込みます(SecureJSON)
+func (r SecureJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	buf, err := codecOf(r.Codec).Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(buf, []byte("[")) {
+		prefix := r.Prefix
+		if prefix == "" {
+			prefix = DefaultSecureJSONPrefix
+		}
+		if _, err = w.Write([]byte(prefix)); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
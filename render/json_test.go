@@ -23,3 +23,35 @@ func TestJson(t *testing.T) {
 	contentRes := w.Header().Get("Content-Type")
 	assert.Equal(t, content, contentRes)
 }
+
+func TestIndentedJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.IndentedJSON{Data: map[string]int{"id": 1}}.Render(w))
+	assert.Equal(t, "{\n    \"id\": 1\n}", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestSecureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.SecureJSON{Data: []int{1, 2}}.Render(w))
+	assert.Equal(t, "while(1);[1,2]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.SecureJSON{Prefix: ")]}',\n", Data: []int{1}}.Render(w))
+	assert.Equal(t, ")]}',\n[1]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.SecureJSON{Data: map[string]int{"id": 1}}.Render(w))
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+}
+
+func TestPureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.PureJSON{Data: map[string]string{"html": "<b>&</b>"}}.Render(w))
+	assert.Equal(t, "{\"html\":\"<b>&</b>\"}\n", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	render.JSON{Data: map[string]string{"html": "<b>"}}.Render(w)
+	assert.Equal(t, `{"html":"\u003cb\u003e"}`, w.Body.String())
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"regexp"
+)
+
+// JSONP `Callback`の関数呼び出しでJSONを書き込みます。`Callback`が空の場合はJSONを書き込みます。
+//     /**/callback({"id":1});
+type JSONP struct {
+	Callback string
+	Data     interface{}
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec
+}
+
+var jsonpContentType = []string{"application/javascript; charset=utf-8"}
+
+// ErrInvalidCallback JSONPのコールバック名がJavaScriptの識別子(`.`区切り)ではない
+var ErrInvalidCallback = errors.New("render: JSONPのコールバック名が不正です")
+
+// jsonpCallback `jQuery123.callback`のような`.`区切りの識別子
+var jsonpCallback = regexp.MustCompile(`^[a-zA-Z_$][0-9a-zA-Z_$]*(\.[a-zA-Z_$][0-9a-zA-Z_$]*)*$`)
+
+const maxCallbackLength = 128
+
+// ValidCallback `callback`がJSONPのコールバック名として使用できるかどうか
+func ValidCallback(callback string) bool {
+	return len(callback) <= maxCallbackLength && jsonpCallback.MatchString(callback)
+}
+
+// Render 与えられたインターフェースオブジェクトをマーシャルし、`Callback`の引数としてデータを書き込みます(JSONP)
+// コールバック名が不正な場合は`ErrInvalidCallback`を返します。
+func (r JSONP) Render(w http.ResponseWriter) error {
+	if r.Callback == "" {
+		return writeJSON(w, r.Codec, r.Data)
+	}
+	if !ValidCallback(r.Callback) {
+		return ErrInvalidCallback
+	}
+	r.WriteContentType(w)
+	buf, err := codecOf(r.Codec).Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	// JavaScriptの文字列では改行として扱われるU+2028、U+2029をエスケープする
+	buf = bytes.Replace(buf, []byte("\u2028"), []byte(`\u2028`), -1)
+	buf = bytes.Replace(buf, []byte("\u2029"), []byte(`\u2029`), -1)
+	var out bytes.Buffer
+	// 先頭のコメントはコールバック名から始まるレスポンスをFlashとして解釈させる攻撃(Rosetta Flash)を防ぐ
+	out.WriteString("/**/" + r.Callback + "(")
+	out.Write(buf)
+	out.WriteString(");")
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r JSONP) WriteContentType(w http.ResponseWriter) {
+	if r.Callback == "" {
+		writeContentType(w, jsonContentType)
+		return
+	}
+	writeContentType(w, jsonpContentType)
+}
@@ -0,0 +1,36 @@
+package render_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONP(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := render.JSONP{Callback: "jQuery1.done", Data: map[string]string{"text": "a\u2028b"}}
+	r.WriteContentType(w)
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, `/**/jQuery1.done({"text":"a\u2028b"});`, w.Body.String())
+	assert.Equal(t, "application/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.JSONP{Data: []int{1}}.Render(w))
+	assert.Equal(t, "[1]", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	assert.Equal(t, render.ErrInvalidCallback, render.JSONP{Callback: "alert(1)//", Data: 1}.Render(w))
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestValidCallback(t *testing.T) {
+	for _, cb := range []string{"cb", "_cb", "$", "jQuery123_456", "app.students.show"} {
+		assert.True(t, render.ValidCallback(cb), cb)
+	}
+	for _, cb := range []string{"", "1cb", "cb()", "cb;alert", "a..b", "a.", "<script>", "a b"} {
+		assert.False(t, render.ValidCallback(cb), cb)
+	}
+}
@@ -0,0 +1,35 @@
+package render
+
+import (
+	"net/http"
+
+	"github.com/ugorji/go/codec"
+)
+
+// MsgPack MessagePack(application/msgpack)で書き込みます。
+type MsgPack struct {
+	Data interface{}
+}
+
+var msgpackContentType = []string{"application/msgpack"}
+
+// MsgPackHandle `MsgPack`と`binding.MsgPack`で使用するエンコード設定
+var MsgPackHandle = newMsgPackHandle()
+
+func newMsgPackHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	// `interface{}`へデコードした文字列を`[]byte`ではなく`string`にする
+	h.RawToString = true
+	return h
+}
+
+// Render 与えられたインターフェースオブジェクトをマーシャルし、カスタムContentTypeでデータを書き込みます。(MessagePack)
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return codec.NewEncoder(w, MsgPackHandle).Encode(r.Data)
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます。
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
@@ -0,0 +1,20 @@
+package render_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestMsgPack(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.MsgPack{Data: map[string]interface{}{"name": "taro", "id": 1}}.Render(w))
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	var data map[string]interface{}
+	assert.Nil(t, codec.NewDecoderBytes(w.Body.Bytes(), render.MsgPackHandle).Decode(&data))
+	assert.Equal(t, "taro", data["name"])
+	assert.EqualValues(t, 1, data["id"])
+}
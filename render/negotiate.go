@@ -0,0 +1,253 @@
+package render
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	mimeJSON  = "application/json"
+	mimeXML   = "application/xml"
+	mimeXML2  = "text/xml"
+	mimeYAML  = "application/x-yaml"
+	mimeHTML  = "text/html"
+	mimePROTO = "application/x-protobuf"
+	mimeMSG   = "application/x-msgpack"
+	mimeMSG2  = "application/msgpack"
+	wildcard  = "*"
+	qualifier = "q"
+)
+
+// ErrNotAcceptable `Negotiation`が対応していない形式
+var ErrNotAcceptable = errors.New("render: 対応していない形式です")
+
+type (
+	// MediaRange `Accept`ヘッダーの1要素
+	//     text/html;level=1;q=0.8
+	MediaRange struct {
+		Type    string
+		Subtype string
+		Params  map[string]string
+		Q       float64
+	}
+
+	// Negotiation `Context.Negotiate`で`Accept`ヘッダーに応じて返すデータ
+	//
+	// `Offered`を省略した場合はJSON、XML、YAML、MessagePack、Protocol Buffers、HTMLの順にデータが設定されている形式を提示します。
+	// 個別のデータが設定されていない形式は`Data`を返します。(Protocol Buffersは`ProtoBuf`を設定した場合のみ)
+	//     c.Negotiate(http.StatusOK, render.Negotiation{
+	//         JSON:     students,
+	//         HTMLName: "students/index.html",
+	//         HTML:     bdx.B{"Students": students},
+	//     })
+	Negotiation struct {
+		Offered  []string
+		Data     interface{}
+		JSON     interface{}
+		XML      interface{}
+		YAML     interface{}
+		MsgPack  interface{}
+		ProtoBuf interface{}
+		HTML     interface{}
+		HTMLName string
+		// Codec JSONのマーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+		Codec JSONCodec
+	}
+)
+
+// NegotiationOffered `Negotiation`が提示する形式
+func (n Negotiation) NegotiationOffered() []string {
+	if len(n.Offered) > 0 {
+		return n.Offered
+	}
+	offered := make([]string, 0, 6)
+	if n.JSON != nil || n.Data != nil {
+		offered = append(offered, mimeJSON)
+	}
+	if n.XML != nil || n.Data != nil {
+		offered = append(offered, mimeXML)
+	}
+	if n.YAML != nil || n.Data != nil {
+		offered = append(offered, mimeYAML)
+	}
+	if n.MsgPack != nil || n.Data != nil {
+		offered = append(offered, mimeMSG2)
+	}
+	if n.ProtoBuf != nil {
+		offered = append(offered, mimePROTO)
+	}
+	if n.HTMLName != "" {
+		offered = append(offered, mimeHTML)
+	}
+	return offered
+}
+
+// Instance `format`に対応する`Render`を返します。
+// HTMLは`templates`の`HTMLName`のテンプレートで描画します。
+// 対応していない形式の場合は`ErrNotAcceptable`を返します。
+func (n Negotiation) Instance(format string, templates *HTMLTemplates) (Render, error) {
+	pick := func(data interface{}) interface{} {
+		if data != nil {
+			return data
+		}
+		return n.Data
+	}
+	switch mediaType(format) {
+	case mimeJSON:
+		return JSON{Data: pick(n.JSON), Codec: n.Codec}, nil
+	case mimeXML, mimeXML2:
+		return XML{Data: pick(n.XML)}, nil
+	case mimeYAML:
+		return YAML{Data: pick(n.YAML)}, nil
+	case mimeMSG, mimeMSG2:
+		return MsgPack{Data: pick(n.MsgPack)}, nil
+	case mimePROTO:
+		return ProtoBuf{Data: n.ProtoBuf}, nil
+	case mimeHTML:
+		if templates == nil {
+			return nil, errors.New("render: HTMLテンプレートが登録されていません")
+		}
+		return templates.Instance(n.HTMLName, pick(n.HTML))
+	default:
+		return nil, ErrNotAcceptable
+	}
+}
+
+// ParseAccept `Accept`ヘッダーを解析し、q値の高い順(同じ場合は具体的な順)に並べて返します。
+// q値が0の要素は受け付けない形式を表すため末尾に並べ、書式が不正な要素は除外します。
+//     ParseAccept("text/*;q=0.5, application/json")
+//     // [{application json map[] 1} {text * map[] 0.5}]
+func ParseAccept(header string) []MediaRange {
+	ranges := make([]MediaRange, 0, 4)
+	for _, part := range strings.Split(header, ",") {
+		m, ok := parseMediaRange(part)
+		if !ok {
+			continue
+		}
+		ranges = append(ranges, m)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Q != ranges[j].Q {
+			return ranges[i].Q > ranges[j].Q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// NegotiateFormat `offered`の中から`Accept`ヘッダーで最も優先される形式を返します。
+// q値が同じ場合は`offered`の先頭を優先します。
+// `Accept`ヘッダーが空の場合は`offered`の先頭を、一致する形式が無い場合は空文字を返します。
+//     NegotiateFormat("application/xml;q=0.9, */*;q=0.1", "application/json", "application/xml") == "application/xml"
+func NegotiateFormat(accept string, offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offered[0]
+	}
+	ranges := ParseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, o := range offered {
+		m, ok := parseMediaRange(o)
+		if !ok {
+			continue
+		}
+		if q := quality(ranges, m); q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best
+}
+
+// quality `ranges`のうち`offer`に一致する最も具体的な要素のq値
+func quality(ranges []MediaRange, offer MediaRange) float64 {
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		if !r.Match(offer) {
+			continue
+		}
+		if s := r.specificity(); s > specificity {
+			q, specificity = r.Q, s
+		}
+	}
+	return q
+}
+
+// Match `offer`がこのメディアレンジに含まれるかどうか
+// `offer`にパラメータがある場合はメディアレンジのパラメータも同じ値である必要があります。
+//     application/json;charset=utf-8 は application/json に一致する
+//     text/html;level=1 は text/html;level=2 に一致しない
+func (r MediaRange) Match(offer MediaRange) bool {
+	if r.Type != wildcard && r.Type != offer.Type {
+		return false
+	}
+	if r.Subtype != wildcard && r.Subtype != offer.Subtype {
+		return false
+	}
+	if len(offer.Params) == 0 {
+		return true
+	}
+	for key, val := range r.Params {
+		if !strings.EqualFold(offer.Params[key], val) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r MediaRange) specificity() int {
+	switch {
+	case r.Type == wildcard:
+		return 0
+	case r.Subtype == wildcard:
+		return 1
+	default:
+		return 2 + len(r.Params)
+	}
+}
+
+func parseMediaRange(s string) (m MediaRange, ok bool) {
+	parts := strings.Split(s, ";")
+	full := strings.ToLower(strings.TrimSpace(parts[0]))
+	slash := strings.IndexByte(full, '/')
+	if slash <= 0 || slash == len(full)-1 {
+		return m, false
+	}
+	m.Type, m.Subtype, m.Q = full[:slash], full[slash+1:], 1
+	if m.Type == wildcard && m.Subtype != wildcard {
+		return m, false
+	}
+	for _, p := range parts[1:] {
+		eq := strings.IndexByte(p, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(p[:eq]))
+		val := strings.Trim(strings.TrimSpace(p[eq+1:]), `"`)
+		if key == qualifier {
+			q, err := strconv.ParseFloat(val, 64)
+			if err != nil || q < 0 || q > 1 {
+				return m, false
+			}
+			m.Q = q
+			// q値より後ろはaccept-extensionのため無視する
+			break
+		}
+		if m.Params == nil {
+			m.Params = make(map[string]string)
+		}
+		m.Params[key] = val
+	}
+	return m, true
+}
+
+// mediaType パラメータを除いたメディアタイプ
+func mediaType(s string) string {
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
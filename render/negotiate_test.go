@@ -0,0 +1,66 @@
+package render_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccept(t *testing.T) {
+	ranges := render.ParseAccept(`text/*;q=0.5, application/json, text/html;level=1, */*;q=0.1, image/png;q=0, bad, text/plain;q=x`)
+	assert.Len(t, ranges, 5)
+	assert.Equal(t, render.MediaRange{Type: "text", Subtype: "html", Params: map[string]string{"level": "1"}, Q: 1}, ranges[0])
+	assert.Equal(t, render.MediaRange{Type: "application", Subtype: "json", Q: 1}, ranges[1])
+	assert.Equal(t, "*", ranges[2].Subtype)
+	assert.Equal(t, 0.5, ranges[2].Q)
+	assert.Equal(t, "*", ranges[3].Type)
+	assert.Equal(t, "png", ranges[4].Subtype)
+	assert.Equal(t, 0.0, ranges[4].Q)
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{"application/json", "application/xml", "text/html"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/xml"},
+		{"application/xml;q=0.9, */*;q=0.1", "application/xml"},
+		{"text/html;q=0.8, application/json;q=0.5", "text/html"},
+		{"text/*, application/json;q=0.9", "text/html"},
+		{"application/json;charset=UTF-8", "application/json"},
+		{"*/*;q=0.5, application/json;q=0", "application/xml"},
+		{"image/png", ""},
+		{"application/xml;q=0", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, render.NegotiateFormat(tt.accept, offered...), tt.accept)
+	}
+	assert.Equal(t, "", render.NegotiateFormat("*/*"))
+	assert.Equal(t, "", render.NegotiateFormat("text/html;level=2", "text/html;level=1"))
+}
+
+func TestNegotiation(t *testing.T) {
+	n := render.Negotiation{Data: page{Title: "Taro"}, JSON: map[string]string{"name": "taro"}}
+	assert.Equal(t, []string{"application/json", "application/xml", "application/x-yaml", "application/msgpack"}, n.NegotiationOffered())
+	assert.Equal(t, []string{"text/csv"}, render.Negotiation{Offered: []string{"text/csv"}}.NegotiationOffered())
+
+	r, err := n.Instance("application/json", nil)
+	assert.Nil(t, err)
+	w := httptest.NewRecorder()
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, `{"name":"taro"}`, w.Body.String())
+
+	r, err = n.Instance("text/xml", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, render.XML{Data: page{Title: "Taro"}}, r)
+
+	_, err = n.Instance("text/csv", nil)
+	assert.Equal(t, render.ErrNotAcceptable, err)
+	_, err = n.Instance("text/html", nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Pagination ページングのメタデータ
+	Pagination struct {
+		Page       int   `json:"page"`
+		PerPage    int   `json:"per_page"`
+		Total      int64 `json:"total"`
+		TotalPages int   `json:"total_pages"`
+	}
+
+	// Paginated 一覧のデータを`Link`ヘッダー(RFC 8288)、`X-Total-Count`ヘッダー、ページングのメタデータと共に書き込みます。
+	//     c.Paginated(200, render.Paginated{Data: students, Page: q.Page, PerPage: q.PerPage, Total: total})
+	Paginated struct {
+		Data    interface{}
+		Page    int
+		PerPage int
+		Total   int64
+		// URL `Link`ヘッダーの基準となるURL(`page`、`per_page`以外のクエリパラメータは引き継がれます)
+		URL *url.URL
+		// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+		Codec JSONCodec
+	}
+
+	paginatedBody struct {
+		Data       interface{} `json:"data"`
+		Pagination Pagination  `json:"pagination"`
+	}
+)
+
+// Pagination ページングのメタデータを返します。
+func (r Paginated) Pagination() Pagination {
+	p := Pagination{Page: r.Page, PerPage: r.PerPage, Total: r.Total}
+	if r.PerPage > 0 {
+		p.TotalPages = int((r.Total + int64(r.PerPage) - 1) / int64(r.PerPage))
+	}
+	return p
+}
+
+// Render 与えられたインターフェースオブジェクトをマーシャルし、カスタムContentTypeでデータを書き込みます(ページング付きJSON)
+func (r Paginated) Render(w http.ResponseWriter) (err error) {
+	r.writeHeader(w)
+	if err = writeJSON(w, r.Codec, paginatedBody{Data: r.Data, Pagination: r.Pagination()}); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// WriteContentType レスポンスにContentTypeとページングのヘッダーを書き込みます
+// `Context.Render`はステータスを書き込む前にこのメソッドを呼ぶため、ヘッダーはここで設定する必要があります。
+func (r Paginated) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+	r.writeHeader(w)
+}
+
+func (r Paginated) writeHeader(w http.ResponseWriter) {
+	header := w.Header()
+	header.Set("X-Total-Count", strconv.FormatInt(r.Total, 10))
+	if link := r.link(r.Pagination()); link != "" {
+		header.Set("Link", link)
+	}
+}
+
+func (r Paginated) link(p Pagination) string {
+	if r.URL == nil || p.PerPage <= 0 {
+		return ""
+	}
+	last := p.TotalPages
+	if last < 1 {
+		last = 1
+	}
+	links := []string{}
+	add := func(page int, rel string) {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(p.PerPage))
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+	add(1, "first")
+	if prev := p.Page - 1; prev > last {
+		add(last, "prev")
+	} else if prev >= 1 {
+		add(prev, "prev")
+	}
+	if p.Page < last {
+		add(p.Page+1, "next")
+	}
+	add(last, "last")
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,49 @@
+package render_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginated(t *testing.T) {
+	u, _ := url.Parse("/students?page=2&per_page=10&sort=name")
+	w := httptest.NewRecorder()
+	p := render.Paginated{Data: []int{1, 2}, Page: 2, PerPage: 10, Total: 25, URL: u}
+	assert.NoError(t, p.Render(w))
+	reader, _ := ioutil.ReadAll(w.Body)
+	assert.Equal(t, `{"data":[1,2],"pagination":{"page":2,"per_page":10,"total":25,"total_pages":3}}`, string(reader))
+	assert.Equal(t, "25", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</students?page=1&per_page=10&sort=name>; rel="first", `+
+		`</students?page=1&per_page=10&sort=name>; rel="prev", `+
+		`</students?page=3&per_page=10&sort=name>; rel="next", `+
+		`</students?page=3&per_page=10&sort=name>; rel="last"`, w.Header().Get("Link"))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestPaginatedEmpty(t *testing.T) {
+	u, _ := url.Parse("/students")
+	w := httptest.NewRecorder()
+	render.Paginated{Data: []int{}, Page: 1, PerPage: 20, URL: u}.Render(w)
+	reader, _ := ioutil.ReadAll(w.Body)
+	assert.Equal(t, `{"data":[],"pagination":{"page":1,"per_page":20,"total":0,"total_pages":0}}`, string(reader))
+	assert.Equal(t, `</students?page=1&per_page=20>; rel="first", </students?page=1&per_page=20>; rel="last"`, w.Header().Get("Link"))
+}
+
+func TestPaginatedHeaderBeforeStatus(t *testing.T) {
+	u, _ := url.Parse("/students")
+	w := httptest.NewRecorder()
+	p := render.Paginated{Data: []int{1}, Page: 1, PerPage: 1, Total: 2, URL: u}
+	// `Context.Render`と同じ順序で書き込み、送信されたヘッダーを確認する
+	p.WriteContentType(w)
+	w.WriteHeader(http.StatusOK)
+	assert.NoError(t, p.Render(w))
+	header := w.Result().Header
+	assert.Equal(t, "2", header.Get("X-Total-Count"))
+	assert.Equal(t, `</students?page=1&per_page=1>; rel="first", </students?page=2&per_page=1>; rel="next", </students?page=2&per_page=1>; rel="last"`, header.Get("Link"))
+}
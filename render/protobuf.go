@@ -0,0 +1,39 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoBuf Protocol Buffers(application/x-protobuf)で書き込みます。`Data`は`proto.Message`である必要があります。
+type ProtoBuf struct {
+	Data interface{}
+}
+
+var protobufContentType = []string{"application/x-protobuf"}
+
+// ErrNotProtoMessage `ProtoBuf`のデータが`proto.Message`ではない
+var ErrNotProtoMessage = errors.New("render: データがproto.Messageではありません")
+
+// Render 与えられたインターフェースオブジェクトをマーシャルし、カスタムContentTypeでデータを書き込みます。(Protocol Buffers)
+// `Data`は`proto.Message`である必要があります。
+func (r ProtoBuf) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	msg, ok := r.Data.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	bytes, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes)
+	return err
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます。
+func (r ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, protobufContentType)
+}
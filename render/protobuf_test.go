@@ -0,0 +1,23 @@
+package render_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoBuf(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.ProtoBuf{Data: wrapperspb.String("太郎")}.Render(w))
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	var msg wrapperspb.StringValue
+	assert.Nil(t, proto.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, "太郎", msg.GetValue())
+
+	w = httptest.NewRecorder()
+	assert.Equal(t, render.ErrNotProtoMessage, render.ProtoBuf{Data: map[string]string{}}.Render(w))
+}
@@ -15,7 +15,23 @@ type Render interface {
 var (
 	_ Render = JSON{}
 	_ Render = JSONAscii{}
+	_ Render = IndentedJSON{}
+	_ Render = SecureJSON{}
+	_ Render = JSONP{}
+	_ Render = PureJSON{}
 	_ Render = YAML{}
+	_ Render = Paginated{}
+	_ Render = HTML{}
+	_ Render = ProtoBuf{}
+	_ Render = MsgPack{}
+	_ Render = NDJSON{}
+	_ Render = JSONArray{}
+	_ Render = CSV{}
+	_ Render = SSE{}
+	_ Render = String{}
+	_ Render = Data{}
+	_ Render = Reader{}
+	_ Render = Redirect{}
 )
 
 func writeContentType(w http.ResponseWriter, value []string) {
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSE Server-Sent Eventsの1イベント
+// `Data`が文字列または`[]byte`以外の場合はJSONにマーシャルします。
+// 複数行のデータは行ごとに`data:`フィールドへ分割します。
+//     id: 42
+//     event: progress
+//     retry: 3000
+//     data: {"percent":50}
+type SSE struct {
+	ID    string
+	Event string
+	Retry time.Duration
+	Data  interface{}
+	// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+	Codec JSONCodec
+}
+
+var (
+	sseContentType = []string{"text/event-stream"}
+	// ssePing ハートビートとして送信するコメント
+	ssePing = []byte(": ping\n\n")
+	// sseLineBreaker フィールドに含めることができない改行
+	sseLineBreaker = strings.NewReplacer("\r\n", "", "\r", "", "\n", "", "\x00", "")
+	sseNewline     = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+)
+
+// Render イベントをテキスト形式で書き込みます。
+// イベントの途中に他の書き込みが混ざらないように1回の`Write`で書き込みます。
+func (r SSE) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	buf, err := r.encode()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r SSE) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, sseContentType)
+}
+
+func (r SSE) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if r.ID != "" {
+		buf.WriteString("id: " + sseLineBreaker.Replace(r.ID) + "\n")
+	}
+	if r.Event != "" {
+		buf.WriteString("event: " + sseLineBreaker.Replace(r.Event) + "\n")
+	}
+	if r.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(int64(r.Retry/time.Millisecond), 10) + "\n")
+	}
+	if r.Data != nil {
+		data, err := sseData(r.Codec, r.Data)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(sseNewline.Replace(data), "\n") {
+			buf.WriteString("data: " + line + "\n")
+		}
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func sseData(codec JSONCodec, data interface{}) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		buf, err := codecOf(codec).Marshal(v)
+		return string(buf), err
+	}
+}
+
+// SSEPing ハートビートとしてコメントを書き込みます。
+// コメントはクライアントに無視されますが、プロキシによる接続の切断を防ぎます。
+func SSEPing(w io.Writer) error {
+	_, err := w.Write(ssePing)
+	return err
+}
@@ -0,0 +1,29 @@
+package render_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSE(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.SSE{ID: "42", Event: "progress", Retry: 3 * time.Second, Data: map[string]int{"percent": 50}}.Render(w))
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 42\nevent: progress\nretry: 3000\ndata: {\"percent\":50}\n\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.SSE{Event: "log\nevent: x", Data: "1行目\r\n2行目\r3行目"}.Render(w))
+	assert.Equal(t, "event: logevent: x\ndata: 1行目\ndata: 2行目\ndata: 3行目\n\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.SSE{ID: "1"}.Render(w))
+	assert.Equal(t, "id: 1\n\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.SSEPing(w))
+	assert.Equal(t, ": ping\n\n", w.Body.String())
+}
@@ -0,0 +1,275 @@
+package render
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+const (
+	// DefaultFlushRows ストリーミングでフラッシュするまでの行数
+	DefaultFlushRows = 100
+	// DefaultFlushInterval ストリーミングでフラッシュするまでの最大の間隔
+	DefaultFlushInterval = time.Second
+)
+
+type (
+	// Rows ストリーミングで書き込む行を1行ずつ返す関数
+	// 全ての行を返した後は`io.EOF`を返します。
+	//     rows, _ := db.Model(&Student{}).Rows()
+	//     defer rows.Close()
+	//     next := func(ctx context.Context) (interface{}, error) {
+	//         if !rows.Next() {
+	//             return nil, io.EOF
+	//         }
+	//         var s Student
+	//         err := db.ScanRows(rows, &s)
+	//         return s, err
+	//     }
+	Rows func(ctx context.Context) (interface{}, error)
+
+	// NDJSON 1行ずつ改行区切りのJSON(application/x-ndjson)で書き込みます。
+	NDJSON struct {
+		Context       context.Context
+		Rows          Rows
+		FlushRows     int
+		FlushInterval time.Duration
+		// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+		Codec JSONCodec
+	}
+
+	// JSONArray 1行ずつJSON配列の要素として書き込みます。
+	JSONArray struct {
+		Context       context.Context
+		Rows          Rows
+		FlushRows     int
+		FlushInterval time.Duration
+		// Codec マーシャルに使用する`JSONCodec`(未指定の場合は`encoding/json`)
+		Codec JSONCodec
+	}
+
+	// fetched `Rows`が返した1行
+	fetched struct {
+		row interface{}
+		err error
+		// panicked `Rows`がpanicした場合の値
+		panicked interface{}
+	}
+
+	// flusher 一定の行数か間隔ごとにフラッシュする
+	flusher struct {
+		w        http.ResponseWriter
+		rows     int
+		interval time.Duration
+		count    int
+		last     time.Time
+		before   func() error
+	}
+)
+
+var ndjsonContentType = []string{"application/x-ndjson"}
+
+// FromChan チャネルから受信した値を返す`Rows`
+// チャネルが閉じられると`io.EOF`を、`ctx`が完了すると`ctx.Err()`を返します。
+//     ch := make(chan Student)
+//     go export(ch)
+//     c.NDJSON(http.StatusOK, render.FromChan(ch))
+func FromChan(ch interface{}) Rows {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("render: FromChanには受信可能なチャネルを指定してください")
+	}
+	return func(ctx context.Context) (interface{}, error) {
+		chosen, recv, ok := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: v},
+		})
+		if chosen == 0 {
+			return nil, ctx.Err()
+		}
+		if !ok {
+			return nil, io.EOF
+		}
+		return recv.Interface(), nil
+	}
+}
+
+func newFlusher(w http.ResponseWriter, rows int, interval time.Duration) *flusher {
+	if rows <= 0 {
+		rows = DefaultFlushRows
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	return &flusher{w: w, rows: rows, interval: interval, last: time.Now()}
+}
+
+// row 1行書き込んだ後に呼び出し、行数か間隔が閾値を超えていればフラッシュします。
+func (f *flusher) row() error {
+	f.count++
+	if f.count < f.rows && time.Since(f.last) < f.interval {
+		return nil
+	}
+	return f.flush()
+}
+
+// pending 前回のフラッシュ以降に書き込んだ行があればフラッシュします。
+func (f *flusher) pending() error {
+	if f.count == 0 {
+		return nil
+	}
+	return f.flush()
+}
+
+func (f *flusher) flush() error {
+	if f.before != nil {
+		if err := f.before(); err != nil {
+			return err
+		}
+	}
+	if fl, ok := f.w.(http.Flusher); ok {
+		fl.Flush()
+	}
+	f.count = 0
+	f.last = time.Now()
+	return nil
+}
+
+// fetch `rows`を別のgoroutineで呼び出し、結果を待つ間は`f`の間隔でフラッシュします。
+// `rows`は1行ずつ要求された時のみ呼び出します。
+// `ctx`が完了した場合は`rows`がコンテキストを無視してブロックしていても待たずに`ctx.Err()`を返します。
+func fetch(ctx context.Context, rows Rows, f *flusher) (next func() (interface{}, error), stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	want := make(chan struct{})
+	// 待つのをやめた後に`rows`が返った場合も送信できるようにバッファを持たせる
+	results := make(chan fetched, 1)
+	go func() {
+		for range want {
+			results <- func() (res fetched) {
+				defer func() {
+					if r := recover(); r != nil {
+						res.panicked = r
+					}
+				}()
+				res.row, res.err = rows(ctx)
+				return
+			}()
+		}
+	}()
+	ticker := time.NewTicker(f.interval)
+	next = func() (interface{}, error) {
+		want <- struct{}{}
+		for {
+			select {
+			case res := <-results:
+				if res.panicked != nil {
+					panic(res.panicked)
+				}
+				return res.row, res.err
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-ticker.C:
+				if err := f.pending(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	stop = func() {
+		ticker.Stop()
+		close(want)
+		cancel()
+	}
+	return next, stop
+}
+
+// stream `rows`を`io.EOF`まで読み込み`write`で書き込み、最後に`end`を書き込みます。
+// `rows`の結果を待つ間も`FlushInterval`ごとに書き込んだ行をフラッシュします。
+// `ctx`が完了した(クライアントが切断した)場合は書き込みを中断し、`nil`を返します。
+func stream(ctx context.Context, rows Rows, f *flusher, write func(row interface{}) error, end func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	next, stop := fetch(ctx, rows, f)
+	defer stop()
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		row, err := next()
+		if err == io.EOF {
+			if end != nil {
+				if err = end(); err != nil {
+					return err
+				}
+			}
+			return f.flush()
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = write(row); err != nil {
+			return err
+		}
+		if err = f.row(); err != nil {
+			return err
+		}
+	}
+}
+
+// Render `Rows`の各行をJSONにマーシャルし、改行区切りで書き込みます。(NDJSON)
+func (r NDJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	f := newFlusher(w, r.FlushRows, r.FlushInterval)
+	codec := codecOf(r.Codec)
+	return stream(r.Context, r.Rows, f, func(row interface{}) error {
+		buf, err := codec.Marshal(row)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(buf, '\n'))
+		return err
+	}, nil)
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r NDJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, ndjsonContentType)
+}
+
+// Render `Rows`の各行をJSONにマーシャルし、JSON配列の要素として書き込みます。
+// 全ての行を書き込んだ場合のみ配列を閉じるため、途中で中断した場合のレスポンスはJSONとして不正になります。
+func (r JSONArray) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if _, err := w.Write([]byte{'['}); err != nil {
+		return err
+	}
+	f := newFlusher(w, r.FlushRows, r.FlushInterval)
+	codec := codecOf(r.Codec)
+	sep := false
+	return stream(r.Context, r.Rows, f, func(row interface{}) error {
+		buf, err := codec.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if sep {
+			buf = append([]byte{','}, buf...)
+		}
+		sep = true
+		_, err = w.Write(buf)
+		return err
+	}, func() error {
+		_, err := w.Write([]byte{']'})
+		return err
+	})
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r JSONArray) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
@@ -0,0 +1,174 @@
+package render_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+// flushRecorder フラッシュした時点の内容を記録する
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []string
+}
+
+func (w *flushRecorder) Flush() {
+	w.flushed = append(w.flushed, w.Body.String())
+}
+
+func sliceRows(rows ...interface{}) render.Rows {
+	return func(ctx context.Context) (interface{}, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := render.NDJSON{Rows: sliceRows(map[string]int{"id": 1}, map[string]int{"id": 2}, map[string]int{"id": 3}), FlushRows: 2}
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n", w.Body.String())
+	assert.Equal(t, []string{"{\"id\":1}\n{\"id\":2}\n", w.Body.String()}, w.flushed)
+}
+
+func TestJSONArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.JSONArray{Rows: sliceRows(1, "a", nil)}.Render(w))
+	assert.Equal(t, `[1,"a",null]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.JSONArray{Rows: sliceRows()}.Render(w))
+	assert.Equal(t, `[]`, w.Body.String())
+
+	failed := errors.New("failed")
+	w = httptest.NewRecorder()
+	assert.Equal(t, failed, render.JSONArray{Rows: func(ctx context.Context) (interface{}, error) {
+		return nil, failed
+	}}.Render(w))
+	assert.Equal(t, `[`, w.Body.String())
+}
+
+func TestCSV(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := render.CSV{
+		Header: []string{"ID", "氏名"},
+		Rows:   sliceRows([]string{"1", "山田 太郎"}, []interface{}{2, "佐藤;花子", nil}),
+		Comma:  ';',
+		BOM:    true,
+	}
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "\xEF\xBB\xBFID;氏名\n1;山田 太郎\n2;\"佐藤;花子\";\n", w.Body.String())
+	assert.Equal(t, []string{w.Body.String()}, w.flushed)
+
+	w = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	assert.Equal(t, render.ErrInvalidCSVRow, render.CSV{Rows: sliceRows(1)}.Render(w))
+}
+
+func TestStreamFlushInterval(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rows := sliceRows(1, 2)
+	r := render.NDJSON{
+		Rows: func(ctx context.Context) (interface{}, error) {
+			time.Sleep(5 * time.Millisecond)
+			return rows(ctx)
+		},
+		FlushInterval: time.Millisecond,
+	}
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, []string{"1\n", "1\n2\n", "1\n2\n"}, w.flushed)
+}
+
+func TestStreamFlushWhileWaiting(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rows := sliceRows(1, 2)
+	calls := 0
+	r := render.NDJSON{
+		Rows: func(ctx context.Context) (interface{}, error) {
+			calls++
+			if calls == 2 {
+				time.Sleep(100 * time.Millisecond)
+			}
+			return rows(ctx)
+		},
+		FlushInterval: 20 * time.Millisecond,
+	}
+	assert.Nil(t, r.Render(w))
+	// 次の行を待つ間に書き込んだ行をフラッシュする
+	assert.Equal(t, "1\n", w.flushed[0])
+	assert.Equal(t, "1\n2\n", w.Body.String())
+}
+
+func TestStreamRowsPanic(t *testing.T) {
+	r := render.NDJSON{
+		Rows: func(ctx context.Context) (interface{}, error) {
+			panic("rows")
+		},
+	}
+	assert.PanicsWithValue(t, "rows", func() {
+		r.Render(httptest.NewRecorder())
+	})
+}
+
+func TestStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+		ch <- 2
+		cancel()
+	}()
+	w := httptest.NewRecorder()
+	done := make(chan error)
+	go func() {
+		done <- render.NDJSON{Context: ctx, Rows: render.FromChan(ch)}.Render(w)
+	}()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ストリーミングが終了しませんでした")
+	}
+	assert.Equal(t, "1\n2\n", w.Body.String())
+
+	close(ch)
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.NDJSON{Rows: render.FromChan(ch)}.Render(w))
+	assert.Equal(t, "", w.Body.String())
+
+	assert.Panics(t, func() { render.FromChan(1) })
+}
+
+func TestStreamCancelBlockedRows(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	rows := func(context.Context) (interface{}, error) {
+		// コンテキストを無視してブロックする
+		<-release
+		return nil, io.EOF
+	}
+	done := make(chan error)
+	go func() {
+		done <- render.NDJSON{Context: ctx, Rows: rows}.Render(httptest.NewRecorder())
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ストリーミングが終了しませんでした")
+	}
+}
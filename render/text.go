@@ -0,0 +1,127 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// String `Format`に`Data`を埋め込んだ文字列を書き込みます。`Data`が空の場合は`Format`をそのまま書き込みます。
+	String struct {
+		Format string
+		Data   []interface{}
+	}
+
+	// Data `ContentType`で`Data`をそのまま書き込みます。
+	Data struct {
+		ContentType string
+		Data        []byte
+	}
+
+	// Reader `Reader`の内容を`ContentType`で書き込みます。
+	// `ContentLength`が負の値の場合は`Content-Length`ヘッダーを設定しません。
+	Reader struct {
+		ContentType   string
+		ContentLength int64
+		Reader        io.Reader
+		Headers       map[string]string
+	}
+
+	// Redirect `Location`へリダイレクトします。
+	// `Code`は3xxまたは201 Createdである必要があります。
+	Redirect struct {
+		Code     int
+		Request  *http.Request
+		Location string
+	}
+)
+
+var plainContentType = []string{"text/plain; charset=utf-8"}
+
+// ErrUnsafeRedirect スキーム相対URL(`//example.com`)など、別のホストへ遷移する相対URL
+var ErrUnsafeRedirect = errors.New("render: 別のホストへ遷移する相対URLにはリダイレクトできません")
+
+// Render 文字列を書き込みます。
+func (r String) Render(w http.ResponseWriter) (err error) {
+	r.WriteContentType(w)
+	if len(r.Data) > 0 {
+		_, err = fmt.Fprintf(w, r.Format, r.Data...)
+		return
+	}
+	_, err = io.WriteString(w, r.Format)
+	return
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r String) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, plainContentType)
+}
+
+// Render データを書き込みます。
+func (r Data) Render(w http.ResponseWriter) (err error) {
+	r.WriteContentType(w)
+	_, err = w.Write(r.Data)
+	return
+}
+
+// WriteContentType レスポンスにContentTypeを書き込みます
+func (r Data) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, []string{r.ContentType})
+}
+
+// Render `Reader`の内容を書き込みます。
+func (r Reader) Render(w http.ResponseWriter) (err error) {
+	r.WriteContentType(w)
+	_, err = io.Copy(w, r.Reader)
+	return
+}
+
+// WriteContentType レスポンスにContentTypeと、`Content-Length`、`Headers`を書き込みます
+func (r Reader) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	for key, val := range r.Headers {
+		if header.Get(key) == "" {
+			header.Set(key, val)
+		}
+	}
+	if r.ContentLength >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(r.ContentLength, 10))
+	}
+	writeContentType(w, []string{r.ContentType})
+}
+
+// Render ステータスコードと`Location`を検証し、`http.Redirect`でリダイレクトします。
+func (r Redirect) Render(w http.ResponseWriter) error {
+	if (r.Code < http.StatusMultipleChoices || r.Code > http.StatusPermanentRedirect) && r.Code != http.StatusCreated {
+		return fmt.Errorf("render: リダイレクトできないステータスコードです: %d", r.Code)
+	}
+	if err := validateLocation(r.Location); err != nil {
+		return err
+	}
+	http.Redirect(w, r.Request, r.Location, r.Code)
+	return nil
+}
+
+// WriteContentType `http.Redirect`が書き込むため何もしません
+func (r Redirect) WriteContentType(http.ResponseWriter) {}
+
+// validateLocation リダイレクト先のURLを検証します。
+// 相対URLはスキーム相対URLや`/\example.com`のようにブラウザが別のホストと解釈するものを拒否します。
+func validateLocation(location string) error {
+	u, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	if u.IsAbs() {
+		return nil
+	}
+	if u.Host != "" || strings.HasPrefix(location, "//") || strings.HasPrefix(location, `/\`) || strings.HasPrefix(location, `\`) {
+		return ErrUnsafeRedirect
+	}
+	return nil
+}
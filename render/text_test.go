@@ -0,0 +1,75 @@
+package render_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/belldata-dx/bdx/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.String{Format: "こんにちは、%sさん", Data: []interface{}{"太郎"}}.Render(w))
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "こんにちは、太郎さん", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.String{Format: "100%"}.Render(w))
+	assert.Equal(t, "100%", w.Body.String())
+}
+
+func TestData(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, render.Data{ContentType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}}.Render(w))
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, w.Body.Bytes())
+}
+
+func TestReader(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := render.Reader{ContentType: "text/csv", ContentLength: 3, Reader: strings.NewReader("a,b"), Headers: map[string]string{"X-Export": "1"}}
+	r.WriteContentType(w)
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+	assert.Equal(t, "1", w.Header().Get("X-Export"))
+	assert.Nil(t, r.Render(w))
+	assert.Equal(t, "a,b", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Nil(t, render.Reader{ContentType: "text/plain", ContentLength: -1, Reader: strings.NewReader("a")}.Render(w))
+	assert.Equal(t, "", w.Header().Get("Content-Length"))
+}
+
+func TestRedirect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/students/new", nil)
+	tests := []struct {
+		code     int
+		location string
+		ok       bool
+	}{
+		{http.StatusFound, "/students", true},
+		{http.StatusSeeOther, "1", true},
+		{http.StatusMovedPermanently, "https://example.com/students", true},
+		{http.StatusCreated, "/students/1", true},
+		{http.StatusOK, "/students", false},
+		{http.StatusBadRequest, "/students", false},
+		{http.StatusFound, "//evil.example.com", false},
+		{http.StatusFound, `/\evil.example.com`, false},
+		{http.StatusFound, "/\t/evil.example.com", false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		err := render.Redirect{Code: tt.code, Request: req, Location: tt.location}.Render(w)
+		if !tt.ok {
+			assert.NotNil(t, err, tt.location)
+			continue
+		}
+		assert.Nil(t, err, tt.location)
+		assert.Equal(t, tt.code, w.Code)
+	}
+	w := httptest.NewRecorder()
+	render.Redirect{Code: http.StatusSeeOther, Request: req, Location: "1"}.Render(w)
+	assert.Equal(t, "/students/1", w.Header().Get("Location"))
+}
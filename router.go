@@ -3,11 +3,13 @@ package bdx
 import (
 	"math"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/belldata-dx/bdx/bdxctx"
 	"github.com/belldata-dx/bdx/interfaces"
 	"github.com/belldata-dx/bdx/param"
+	"github.com/belldata-dx/bdx/websocket"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -43,8 +45,17 @@ func (group *RouterGroup) Group(relativePath string, handlers ...interfaces.BdxH
 // Handler 新しいリクエストハンドルとミドルウェアを与えられたパスとメソッドで登録します。
 // 最後のハンドルが実際のハンドルとして登録されます。
 // それ以外はミドルウェアでなければいけません。
+//
+// パスパラメータには`/students/:id<int>`のように制約を指定できます。
+// 制約を満たさないリクエストはハンドラを実行せずに`Engine.SetConstraintStatus`のステータス(デフォルト404)を返します。
+// 制約の検証は`Use`、`Group`、`handlers`で指定したミドルウェアを全て実行した後、最後のハンドルの直前に行うため、
+// ミドルウェアはそのステータスを参照できます。
 func (group *RouterGroup) Handler(method, relativePath string, handlers ...interfaces.BdxHandlerFunc) interfaces.Routes {
-	absolutePath := group.calculateAbsolutePath(relativePath)
+	absolutePath, constraints := parseConstraints(group.calculateAbsolutePath(relativePath))
+	if len(constraints) > 0 && len(handlers) > 0 {
+		last := len(handlers) - 1
+		handlers = append(handlers[:last:last], group.checkConstraints(constraints), handlers[last])
+	}
 	handlers = group.combineHandlers(handlers)
 	group.route.Handle(method, absolutePath, func(w http.ResponseWriter, rq *http.Request, pm httprouter.Params) {
 		c := rq.Context().Value(ContextKey).(*bdxctx.Context)
@@ -66,6 +77,16 @@ func (group *RouterGroup) Handler(method, relativePath string, handlers ...inter
 	return group.returnObj()
 }
 
+// checkConstraints パスパラメータが制約を満たさない場合に後続のハンドラを実行せずに終了するハンドラ
+func (group *RouterGroup) checkConstraints(constraints routeConstraints) interfaces.BdxHandlerFunc {
+	return func(c interfaces.Context) {
+		if !constraints.match(c.Params()) {
+			status := group.engine.constraintStatus
+			c.AbortWithStatusAndMessage(status, []byte(http.StatusText(status)))
+		}
+	}
+}
+
 // GET は`router.Handler("GET", path, handle)`のショートカットです。
 func (group *RouterGroup) GET(relativePath string, handlers ...interfaces.BdxHandlerFunc) interfaces.Routes {
 	group.Handler(http.MethodGet, relativePath, handlers...)
@@ -96,6 +117,34 @@ func (group *RouterGroup) OPTIONS(relativePath string, handlers ...interfaces.Bd
 	return group.returnObj()
 }
 
+// WS WebSocketのハンドラを登録します。
+// グループのミドルウェアと`handlers`(認証など)を実行した後にアップグレードし、`handler`を実行します。
+// ミドルウェアで中断した場合はアップグレードしません。
+// `handler`が終了すると接続を閉じます。`Engine.Shutdown`では1001 Going Awayで閉じます。
+//     router.WS("/notifications", func(c interfaces.Context, conn *websocket.Conn) {
+//         for n := range subscribe(c.Request().Context()) {
+//             if err := conn.WriteJSON(n); err != nil {
+//                 return
+//             }
+//         }
+//     }, auth)
+func (group *RouterGroup) WS(relativePath string, handler interfaces.WSHandler, handlers ...interfaces.BdxHandlerFunc) interfaces.Routes {
+	upgrade := func(c interfaces.Context) {
+		conn, err := websocket.Upgrade(c.Response(), c.Request(), group.engine.wsOptions)
+		if err != nil {
+			c.SetError(err)
+			c.Abort()
+			return
+		}
+		defer conn.Close(websocket.CloseNormalClosure, "")
+		group.engine.trackWebSocket(conn, true)
+		defer group.engine.trackWebSocket(conn, false)
+		handler(c, conn)
+	}
+	group.Handler(http.MethodGet, relativePath, append(handlers[:len(handlers):len(handlers)], upgrade)...)
+	return group.returnObj()
+}
+
 // Any は`GET`,`POST`,`PUT`,`DELETE`のショートカットです。
 func (group *RouterGroup) Any(relativePath string, handlers ...interfaces.BdxHandlerFunc) interfaces.Routes {
 	group.GET(relativePath, handlers...)
@@ -150,3 +199,40 @@ func (group *RouterGroup) returnObj() interfaces.Routes {
 	}
 	return group
 }
+
+type (
+	// routeConstraint パスパラメータ1つ分の制約
+	routeConstraint struct {
+		key        string
+		constraint param.Constraint
+	}
+	routeConstraints []routeConstraint
+)
+
+// parseConstraints パスから`<制約名>`を取り除き、パスパラメータの制約を返します。
+// 登録されていない制約名の場合はpanicします。
+func parseConstraints(path string) (string, routeConstraints) {
+	var constraints routeConstraints
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		start := strings.IndexByte(segment, '<')
+		if start < 0 || !strings.HasPrefix(segment, ":") || !strings.HasSuffix(segment, ">") {
+			continue
+		}
+		key, name := segment[1:start], segment[start+1:len(segment)-1]
+		constraint, ok := param.LookupConstraint(name)
+		assert1(ok, "unknown route constraint '"+name+"' in path '"+path+"'")
+		constraints = append(constraints, routeConstraint{key: key, constraint: constraint})
+		segments[i] = segment[:start]
+	}
+	return strings.Join(segments, "/"), constraints
+}
+
+func (rc routeConstraints) match(params param.Params) bool {
+	for _, c := range rc {
+		if !c.constraint(params.ByName(c.key)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/belldata-dx/bdx/cookie"
+)
+
+// CookieStore セッションの内容を暗号化してCookieに保存するストア
+//
+// サーバ側に状態を持ちませんが、Cookieの容量(約4KB)を超える値は保存できません。
+// また、サーバ側から個別のセッションを失効させることはできません。
+type CookieStore struct {
+	codec cookie.Config
+}
+
+// NewCookieStore 暗号化の鍵を指定してCookieStoreを生成します。
+// 先頭の鍵で暗号化し、全ての鍵で復号します。
+func NewCookieStore(keys ...[]byte) *CookieStore {
+	return &CookieStore{codec: cookie.Config{Keys: keys}}
+}
+
+var _ Store = &CookieStore{}
+
+type cookieRecord struct {
+	*Record
+	Expire time.Time `json:"expire"`
+}
+
+// Load Cookieの値を復号してセッションを読み込みます。復号できない場合は`nil`を返します。
+func (s *CookieStore) Load(ctx context.Context, value string) (*Record, error) {
+	data, err := s.codec.Decrypt(DefaultCookieName, value)
+	if err != nil {
+		return nil, nil
+	}
+	r := cookieRecord{Record: &Record{}}
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return nil, err
+	}
+	if time.Now().After(r.Expire) {
+		return nil, nil
+	}
+	return r.Record, nil
+}
+
+// Save セッションの内容を暗号化した値を返します。
+func (s *CookieStore) Save(ctx context.Context, record *Record, ttl time.Duration) (string, error) {
+	data, err := json.Marshal(cookieRecord{Record: record, Expire: time.Now().Add(ttl)})
+	if err != nil {
+		return "", err
+	}
+	return s.codec.Encrypt(DefaultCookieName, string(data))
+}
+
+// Delete Cookieに保存しているため何もしません。
+func (s *CookieStore) Delete(ctx context.Context, id string) error {
+	return nil
+}
@@ -0,0 +1,268 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/belldata-dx/bdx/interfaces"
+)
+
+const (
+	// DefaultCookieName `Options.CookieName`が未指定の場合のCookie名
+	DefaultCookieName = "bdx_session"
+	// DefaultIdleTimeout `Options.IdleTimeout`が未指定の場合の無操作タイムアウト
+	DefaultIdleTimeout = 30 * time.Minute
+	// DefaultAbsoluteTimeout `Options.AbsoluteTimeout`が未指定の場合の絶対タイムアウト
+	DefaultAbsoluteTimeout = 24 * time.Hour
+)
+
+type (
+	sessionKey struct{}
+
+	// Options セッションの設定
+	Options struct {
+		// CookieName セッションを保持するCookie名(デフォルト`bdx_session`)
+		CookieName string
+		// IdleTimeout 最後のアクセスからセッションが失効するまでの時間(デフォルト30分)
+		IdleTimeout time.Duration
+		// AbsoluteTimeout セッションの生成からアクセスに関わらず失効するまでの時間(デフォルト24時間)
+		AbsoluteTimeout time.Duration
+		// CookieMaxAge CookieのMax-Age(0の場合はブラウザを閉じるまで)
+		CookieMaxAge int
+	}
+
+	// Record ストアに保存するセッションの内容
+	//
+	// 値はJSONで保存するため、読み込み後の数値は`float64`になります。
+	Record struct {
+		ID         string                 `json:"id"`
+		Values     map[string]interface{} `json:"values"`
+		Flashes    []interface{}          `json:"flashes,omitempty"`
+		CreatedAt  time.Time              `json:"created_at"`
+		AccessedAt time.Time              `json:"accessed_at"`
+	}
+
+	// State リクエスト中のセッション
+	State struct {
+		mu        sync.Mutex
+		record    *Record
+		oldID     string
+		destroyed bool
+		// loaded ストアから読み込んだセッションか
+		loaded bool
+		// modified 値、フラッシュメッセージ、IDを変更したか
+		modified bool
+	}
+)
+
+func (o Options) withDefaults() Options {
+	if o.CookieName == "" {
+		o.CookieName = DefaultCookieName
+	}
+	if o.IdleTimeout == 0 {
+		o.IdleTimeout = DefaultIdleTimeout
+	}
+	if o.AbsoluteTimeout == 0 {
+		o.AbsoluteTimeout = DefaultAbsoluteTimeout
+	}
+	return o
+}
+
+// expired `now`の時点で失効しているか
+func (o Options) expired(r *Record, now time.Time) bool {
+	return now.Sub(r.AccessedAt) > o.IdleTimeout || now.Sub(r.CreatedAt) > o.AbsoluteTimeout
+}
+
+// ttl ストアに保存する期間
+func (o Options) ttl(r *Record) time.Duration {
+	ttl := o.IdleTimeout
+	if remain := time.Until(r.CreatedAt.Add(o.AbsoluteTimeout)); remain < ttl {
+		ttl = remain
+	}
+	return ttl
+}
+
+// NewID 推測できないセッションIDを生成します。
+func NewID() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func newRecord(now time.Time) *Record {
+	return &Record{ID: NewID(), Values: map[string]interface{}{}, CreatedAt: now, AccessedAt: now}
+}
+
+// ID セッションID
+func (s *State) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record.ID
+}
+
+// CreatedAt セッションの生成日時
+func (s *State) CreatedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record.CreatedAt
+}
+
+// Get 値を返します。存在しない場合は`nil`を返します。
+func (s *State) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record.Values[key]
+}
+
+// Set 値を設定します。
+func (s *State) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Values[key] = value
+	s.modified = true
+}
+
+// Delete 値を削除します。
+func (s *State) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.record.Values[key]; ok {
+		delete(s.record.Values, key)
+		s.modified = true
+	}
+}
+
+// AddFlash 次に`Flashes`を呼び出すまで保持するメッセージを追加します。
+//     sessions.Session(c).AddFlash("保存しました。")
+func (s *State) AddFlash(value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Flashes = append(s.record.Flashes, value)
+	s.modified = true
+}
+
+// Flashes `AddFlash`で追加したメッセージを返し、削除します。
+func (s *State) Flashes() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flashes := s.record.Flashes
+	if len(flashes) > 0 {
+		s.record.Flashes = nil
+		s.modified = true
+	}
+	return flashes
+}
+
+// RotateID 値を引き継いだままセッションIDを変更します。
+// セッション固定攻撃を防ぐため、ログインなど権限が変わる際に呼び出してください。
+func (s *State) RotateID() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oldID == "" {
+		s.oldID = s.record.ID
+	}
+	s.record.ID = NewID()
+	s.modified = true
+}
+
+// Destroy セッションを破棄します。以降の操作は新しいセッションに対して行われます。
+func (s *State) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oldID == "" {
+		s.oldID = s.record.ID
+	}
+	s.destroyed = true
+	s.loaded = false
+	s.modified = false
+	s.record = newRecord(time.Now())
+}
+
+// Session `Middleware`が読み込んだセッションを返します。`Middleware`を使用していない場合は`nil`を返します。
+func Session(c interfaces.Context) *State {
+	s, _ := c.Request().Context().Value(sessionKey{}).(*State)
+	return s
+}
+
+// Middleware セッションを読み込み、レスポンスの書き込み前にストアへ保存するミドルウェア
+//
+// 読み込んだセッションと、値を設定するなど変更したセッションのみ保存するため、匿名のリクエストではCookieを発行しません。
+//     router.Use(sessions.Middleware(sessions.NewMemoryStore(), sessions.Options{IdleTimeout: time.Hour}))
+//     router.POST("/login", func(c interfaces.Context) {
+//         s := sessions.Session(c)
+//         s.RotateID()
+//         s.Set("user_id", id)
+//     })
+func Middleware(store Store, opts Options) interfaces.BdxHandlerFunc {
+	opts = opts.withDefaults()
+	return func(c interfaces.Context) {
+		req := c.Request()
+		ctx := req.Context()
+		now := time.Now()
+
+		var record *Record
+		if value, err := c.Cookie(opts.CookieName); err == nil {
+			if record, err = store.Load(ctx, value); err != nil {
+				c.Logger().Warnf("セッションの読み込みエラー: %v", err)
+			}
+		}
+		s := &State{}
+		if record != nil && opts.expired(record, now) {
+			s.oldID = record.ID
+			record = nil
+		}
+		s.loaded = record != nil
+		if record == nil {
+			record = newRecord(now)
+		}
+		if record.Values == nil {
+			record.Values = map[string]interface{}{}
+		}
+		s.record = record
+
+		var once sync.Once
+		save := func() {
+			once.Do(func() {
+				if err := s.save(c, ctx, store, opts); err != nil {
+					c.Logger().Errorf("セッションの保存エラー: %v", err)
+				}
+			})
+		}
+		c.BeforeWrite(save)
+		c.SetRequest(req.WithContext(context.WithValue(ctx, sessionKey{}, s)))
+		c.Next()
+		if c.ResponseStatus() == 0 {
+			save()
+		}
+	}
+}
+
+// save 古いセッションを削除し、現在のセッションを保存してCookieを設定します。
+// 新しいセッションを変更していない場合は保存せず、破棄または失効したセッションのCookieを削除します。
+func (s *State) save(c interfaces.Context, ctx context.Context, store Store, opts Options) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oldID != "" {
+		if err := store.Delete(ctx, s.oldID); err != nil {
+			return err
+		}
+	}
+	if !s.loaded && !s.modified {
+		if s.oldID != "" {
+			c.SetCookie(opts.CookieName, "", -1)
+		}
+		return nil
+	}
+	s.record.AccessedAt = time.Now()
+	value, err := store.Save(ctx, s.record, opts.ttl(s.record))
+	if err != nil {
+		return err
+	}
+	c.SetCookie(opts.CookieName, value, opts.CookieMaxAge)
+	return nil
+}
@@ -0,0 +1,124 @@
+package sessions_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx"
+	"github.com/belldata-dx/bdx/cookie"
+	"github.com/belldata-dx/bdx/interfaces"
+	"github.com/belldata-dx/bdx/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+type client struct {
+	t      *testing.T
+	router http.Handler
+	cookie *http.Cookie
+}
+
+func (cl *client) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cl.cookie != nil {
+		req.AddCookie(cl.cookie)
+	}
+	w := httptest.NewRecorder()
+	cl.router.ServeHTTP(w, req)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessions.DefaultCookieName {
+			cl.cookie = c
+			if c.MaxAge < 0 {
+				cl.cookie = nil
+			}
+		}
+	}
+	return w
+}
+
+func newRouter(store sessions.Store, opts sessions.Options) *bdx.Engine {
+	router := bdx.New()
+	router.Use(sessions.Middleware(store, opts))
+	router.GET("/login", func(c interfaces.Context) {
+		s := sessions.Session(c)
+		s.RotateID()
+		s.Set("user", "taro")
+		s.AddFlash("ログインしました。")
+		c.JSON(http.StatusOK, bdx.B{})
+	})
+	router.GET("/me", func(c interfaces.Context) {
+		s := sessions.Session(c)
+		c.JSON(http.StatusOK, bdx.B{"user": s.Get("user"), "flashes": s.Flashes()})
+	})
+	router.GET("/logout", func(c interfaces.Context) {
+		sessions.Session(c).Destroy()
+	})
+	return router
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := sessions.NewMemoryStore()
+	cl := &client{t: t, router: newRouter(store, sessions.Options{})}
+
+	w := cl.get("/me")
+	assert.Empty(t, w.Result().Cookies())
+	assert.Equal(t, 0, store.Len())
+
+	cl.get("/login")
+	assert.NotNil(t, cl.cookie)
+	assert.True(t, cl.cookie.HttpOnly)
+	assert.Equal(t, 1, store.Len())
+
+	w = cl.get("/me")
+	assert.Equal(t, `{"flashes":["ログインしました。"],"user":"taro"}`, w.Body.String())
+	w = cl.get("/me")
+	assert.Equal(t, `{"flashes":null,"user":"taro"}`, w.Body.String())
+
+	cl.get("/logout")
+	assert.Nil(t, cl.cookie)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestDestroyCookie(t *testing.T) {
+	router := newRouter(sessions.NewMemoryStore(), sessions.Options{})
+	router.SetCookieConfig(cookie.Config{Path: "/app", Domain: "example.com"})
+	cl := &client{t: t, router: router}
+	cl.get("/login")
+
+	w := cl.get("/logout")
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, -1, cookies[0].MaxAge)
+		assert.Equal(t, "/app", cookies[0].Path)
+		assert.Equal(t, "example.com", cookies[0].Domain)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	store := sessions.NewMemoryStore()
+	cl := &client{t: t, router: newRouter(store, sessions.Options{IdleTimeout: 50 * time.Millisecond, AbsoluteTimeout: 120 * time.Millisecond})}
+	cl.get("/login")
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, `{"flashes":["ログインしました。"],"user":"taro"}`, cl.get("/me").Body.String())
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, `{"flashes":null,"user":null}`, cl.get("/me").Body.String())
+
+	cl.get("/login")
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+		cl.get("/me")
+	}
+	assert.Equal(t, `{"flashes":null,"user":null}`, cl.get("/me").Body.String())
+}
+
+func TestCookieStore(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("secret"))
+	cl := &client{t: t, router: newRouter(store, sessions.Options{})}
+	cl.get("/login")
+	assert.NotContains(t, cl.cookie.Value, "taro")
+	assert.Equal(t, `{"flashes":["ログインしました。"],"user":"taro"}`, cl.get("/me").Body.String())
+
+	other := &client{t: t, router: newRouter(sessions.NewCookieStore([]byte("other")), sessions.Options{}), cookie: cl.cookie}
+	assert.Equal(t, `{"flashes":null,"user":null}`, other.get("/me").Body.String())
+}
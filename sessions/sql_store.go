@@ -0,0 +1,100 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/belldata-dx/bdx/infra"
+	"github.com/jinzhu/gorm"
+)
+
+// DefaultTable `SQLStore`のデフォルトのテーブル名
+const DefaultTable = "sessions"
+
+// SQLStore `infra.DB`のReadWrite Nodeにセッションを保存するストア
+//     store := sessions.NewSQLStore(db)
+//     if err := store.CreateTable(); err != nil { ... }
+type SQLStore struct {
+	// Table テーブル名(デフォルト`sessions`)
+	Table string
+	db    *infra.DB
+}
+
+var _ Store = &SQLStore{}
+
+// NewSQLStore SQLStoreを生成します。
+func NewSQLStore(db *infra.DB) *SQLStore {
+	return &SQLStore{Table: DefaultTable, db: db}
+}
+
+func (s *SQLStore) table() string {
+	if schema := s.db.Schema(); schema != "" && s.db.Dialect() == infra.Postgres {
+		return schema + "." + s.Table
+	}
+	return s.Table
+}
+
+// conn レプリケーション遅延の影響を受けないよう、読み込みもReadWrite Nodeで行います。
+func (s *SQLStore) conn(ctx context.Context) *gorm.DB {
+	return s.db.WriterContext(ctx)
+}
+
+// CreateTable セッションを保存するテーブルが無ければ作成します。
+func (s *SQLStore) CreateTable() error {
+	return s.conn(context.Background()).Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) PRIMARY KEY, data TEXT NOT NULL, expires_at TIMESTAMP NOT NULL)",
+		s.table())).Error
+}
+
+// Load Cookieの値(セッションID)からセッションを読み込みます。
+func (s *SQLStore) Load(ctx context.Context, value string) (*Record, error) {
+	var row struct {
+		Data      string
+		ExpiresAt time.Time
+	}
+	err := s.conn(ctx).Raw(fmt.Sprintf("SELECT data, expires_at FROM %s WHERE id = ?", s.table()), value).Scan(&row).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(row.ExpiresAt) {
+		return nil, nil
+	}
+	record := &Record{}
+	if err := json.Unmarshal([]byte(row.Data), record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Save セッションを保存し、セッションIDを返します。
+func (s *SQLStore) Save(ctx context.Context, record *Record, ttl time.Duration) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	expire := time.Now().Add(ttl).UTC()
+	db := s.conn(ctx)
+	result := db.Exec(fmt.Sprintf("UPDATE %s SET data = ?, expires_at = ? WHERE id = ?", s.table()), string(data), expire, record.ID)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		err = db.Exec(fmt.Sprintf("INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?)", s.table()), record.ID, string(data), expire).Error
+	}
+	return record.ID, err
+}
+
+// Delete セッションを削除します。
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	return s.conn(ctx).Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", s.table()), id).Error
+}
+
+// Cleanup 失効したセッションを削除します。定期的に実行してください。
+func (s *SQLStore) Cleanup(ctx context.Context) error {
+	return s.conn(ctx).Exec(fmt.Sprintf("DELETE FROM %s WHERE expires_at < ?", s.table()), time.Now().UTC()).Error
+}
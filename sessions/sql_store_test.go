@@ -0,0 +1,39 @@
+package sessions_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belldata-dx/bdx/infra"
+	"github.com/belldata-dx/bdx/internal/sqlitetest"
+	"github.com/belldata-dx/bdx/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLStore(t *testing.T) {
+	sqlitetest.Skip(t)
+	db, err := infra.Open(&infra.Config{Dialect: infra.SQLite, LogMode: false})
+	assert.Nil(t, err)
+	defer db.Close()
+	store := sessions.NewSQLStore(db)
+	assert.Nil(t, store.CreateTable())
+
+	cl := &client{t: t, router: newRouter(store, sessions.Options{})}
+	cl.get("/login")
+	assert.Equal(t, `{"flashes":["ログインしました。"],"user":"taro"}`, cl.get("/me").Body.String())
+	cl.get("/logout")
+	assert.Nil(t, cl.cookie)
+
+	ctx := context.Background()
+	now := time.Now()
+	_, err = store.Save(ctx, &sessions.Record{ID: "expired", CreatedAt: now, AccessedAt: now}, -time.Second)
+	assert.Nil(t, err)
+	record, err := store.Load(ctx, "expired")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+	assert.Nil(t, store.Cleanup(ctx))
+	var count int
+	assert.Nil(t, db.Writer().Table(sessions.DefaultTable).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
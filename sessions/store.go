@@ -0,0 +1,97 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Store セッションの保存先
+//
+// Cookieにはセッションの内容そのもの、もしくは内容を参照するための値を保存します。
+type Store interface {
+	// Load Cookieの値からセッションを読み込みます。存在しない、もしくは失効している場合は`nil`を返します。
+	Load(ctx context.Context, value string) (*Record, error)
+	// Save セッションを`ttl`の間保存し、Cookieに設定する値を返します。
+	Save(ctx context.Context, record *Record, ttl time.Duration) (string, error)
+	// Delete セッションIDが`id`のセッションを削除します。
+	Delete(ctx context.Context, id string) error
+}
+
+type memoryEntry struct {
+	data   []byte
+	expire time.Time
+}
+
+// MemoryStore プロセス内のメモリにセッションを保存するストア
+//
+// 複数のインスタンスで共有できないため、開発や単一インスタンスでの利用を想定しています。
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	saved   int
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore MemoryStoreを生成します。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+// Load Cookieの値(セッションID)からセッションを読み込みます。
+func (m *MemoryStore) Load(ctx context.Context, value string) (*Record, error) {
+	m.mu.Lock()
+	entry, ok := m.entries[value]
+	m.mu.Unlock()
+	if !ok || time.Now().After(entry.expire) {
+		return nil, nil
+	}
+	record := &Record{}
+	if err := json.Unmarshal(entry.data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Save セッションを保存し、セッションIDを返します。
+func (m *MemoryStore) Save(ctx context.Context, record *Record, ttl time.Duration) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[record.ID] = memoryEntry{data: data, expire: time.Now().Add(ttl)}
+	m.saved++
+	if m.saved%100 == 0 {
+		m.sweep()
+	}
+	return record.ID, nil
+}
+
+// Delete セッションを削除します。
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, id)
+	return nil
+}
+
+// Len 保存されているセッションの数
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// sweep 失効したセッションを削除します。
+func (m *MemoryStore) sweep() {
+	now := time.Now()
+	for id, entry := range m.entries {
+		if now.After(entry.expire) {
+			delete(m.entries, id)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// メッセージの種類(RFC 6455 5.2 Opcode)
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// Closeフレームのステータスコード(RFC 6455 7.4.1)
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+const (
+	finBit  = 1 << 7
+	rsvBits = 7 << 4
+	maskBit = 1 << 7
+
+	maxControlPayload = 125
+)
+
+var (
+	// ErrReadLimit メッセージが`Options.ReadLimit`を超えた
+	ErrReadLimit = errors.New("websocket: メッセージが大きすぎます")
+	// ErrClosed 接続が閉じられている
+	ErrClosed = errors.New("websocket: 接続は閉じられています")
+)
+
+type (
+	// CloseError クライアントから受信したCloseフレーム、またはプロトコル違反により送信したCloseフレーム
+	CloseError struct {
+		Code int
+		Text string
+	}
+
+	// protocolError プロトコル違反
+	protocolError struct {
+		code int
+		text string
+	}
+
+	// Conn WebSocketの接続
+	// `ReadMessage`は1つのgoroutineから、`WriteMessage`などの書き込みは複数のgoroutineから呼び出すことができます。
+	Conn struct {
+		conn        net.Conn
+		br          *bufio.Reader
+		subprotocol string
+		readLimit   int64
+		pongTimeout time.Duration
+		writeWait   time.Duration
+
+		wmu       sync.Mutex
+		closeOnce sync.Once
+		closeSent bool
+		done      chan struct{}
+	}
+)
+
+func (e *CloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(e.Code) + " " + e.Text
+}
+
+func (e *protocolError) Error() string {
+	return "websocket: " + e.text
+}
+
+// IsCloseError `err`が`codes`のいずれかのステータスコードの`CloseError`かどうか
+//     if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+//         return
+//     }
+func IsCloseError(err error, codes ...int) bool {
+	var ce *CloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	for _, code := range codes {
+		if ce.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, subprotocol string, opts Options) *Conn {
+	c := &Conn{
+		conn:        conn,
+		br:          br,
+		subprotocol: subprotocol,
+		readLimit:   opts.ReadLimit,
+		pongTimeout: opts.PongTimeout,
+		writeWait:   opts.WriteTimeout,
+		done:        make(chan struct{}),
+	}
+	if c.pongTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+	}
+	if opts.PingInterval > 0 {
+		go c.keepAlive(opts.PingInterval)
+	}
+	return c
+}
+
+// Subprotocol ハンドシェイクで合意したサブプロトコル
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr クライアントのアドレス
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline 読み込みの期限を設定します。
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// ReadMessage 次のメッセージを読み込みます。
+// 分割されたフレームは結合して返し、Ping、Pongフレームは内部で処理します。
+// Closeフレームを受信した場合は応答のCloseフレームを送信し、`*CloseError`を返します。
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	messageType = -1
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return -1, nil, c.fail(err)
+		}
+		if c.pongTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+		}
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return -1, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return -1, nil, c.handleClose(payload)
+		case TextMessage, BinaryMessage:
+			if messageType != -1 {
+				return -1, nil, c.fail(&protocolError{CloseProtocolError, "分割中のメッセージに新しいメッセージが送信されました"})
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == -1 {
+				return -1, nil, c.fail(&protocolError{CloseProtocolError, "継続するメッセージがありません"})
+			}
+		default:
+			return -1, nil, c.fail(&protocolError{CloseProtocolError, "不明なopcodeです: " + strconv.Itoa(opcode)})
+		}
+		if c.readLimit > 0 && int64(len(data)+len(payload)) > c.readLimit {
+			return -1, nil, c.fail(ErrReadLimit)
+		}
+		data = append(data, payload...)
+		if !fin {
+			continue
+		}
+		if messageType == TextMessage && !utf8.Valid(data) {
+			return -1, nil, c.fail(&protocolError{CloseInvalidFramePayloadData, "テキストメッセージがUTF-8ではありません"})
+		}
+		return messageType, data, nil
+	}
+}
+
+// ReadJSON 次のメッセージをJSONとして`v`にデコードします。
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage メッセージを1つのフレームで送信します。
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: 不正なメッセージの種類です: " + strconv.Itoa(messageType))
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteJSON `v`をJSONにマーシャルしてテキストメッセージで送信します。
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(TextMessage, data)
+}
+
+// Ping Pingフレームを送信します。
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: 制御フレームのデータが大きすぎます")
+	}
+	return c.writeFrame(PingMessage, data)
+}
+
+// Close `code`と`reason`のCloseフレームを送信して接続を閉じます。
+// 既にCloseフレームを送信している場合は接続のみ閉じます。
+func (c *Conn) Close(code int, reason string) error {
+	c.sendClose(code, reason)
+	return c.closeConn()
+}
+
+func (c *Conn) closeConn() (err error) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// sendClose Closeフレームを1度だけ送信します。
+func (c *Conn) sendClose(code int, reason string) error {
+	c.wmu.Lock()
+	if c.closeSent {
+		c.wmu.Unlock()
+		return ErrClosed
+	}
+	c.wmu.Unlock()
+	var payload []byte
+	if code != CloseNoStatusReceived {
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+	}
+	return c.writeFrame(CloseMessage, payload)
+}
+
+// handleClose 受信したCloseフレームに同じステータスコードで応答します。
+func (c *Conn) handleClose(payload []byte) error {
+	ce := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(&protocolError{CloseProtocolError, "Closeフレームが不正です"})
+	case len(payload) >= 2:
+		ce.Code = int(binary.BigEndian.Uint16(payload))
+		ce.Text = string(payload[2:])
+		if !validCloseCode(ce.Code) {
+			return c.fail(&protocolError{CloseProtocolError, "不正なステータスコードです: " + strconv.Itoa(ce.Code)})
+		}
+		if !utf8.ValidString(ce.Text) {
+			return c.fail(&protocolError{CloseInvalidFramePayloadData, "Closeフレームの理由がUTF-8ではありません"})
+		}
+	}
+	c.sendClose(ce.Code, "")
+	c.closeConn()
+	return ce
+}
+
+// fail 読み込みエラーに応じたCloseフレームを送信して接続を閉じます。
+func (c *Conn) fail(err error) error {
+	var pe *protocolError
+	switch {
+	case errors.As(err, &pe):
+		c.sendClose(pe.code, pe.text)
+		c.closeConn()
+		return &CloseError{Code: pe.code, Text: pe.text}
+	case err == ErrReadLimit:
+		c.sendClose(CloseMessageTooBig, "")
+		c.closeConn()
+		return err
+	default:
+		// 接続が切れているためCloseフレームは送信しない
+		c.closeConn()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return &CloseError{Code: CloseAbnormalClosure, Text: err.Error()}
+		}
+		return err
+	}
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	default:
+		return false
+	}
+}
+
+// readFrame フレームを1つ読み込み、マスクを解除したデータを返します。
+// クライアントからのフレームはマスクされている必要があります。
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&finBit != 0
+	opcode = int(header[0] & 0x0f)
+	if header[0]&rsvBits != 0 {
+		err = &protocolError{CloseProtocolError, "拡張は使用できません"}
+		return
+	}
+	if header[1]&maskBit == 0 {
+		err = &protocolError{CloseProtocolError, "クライアントのフレームがマスクされていません"}
+		return
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= CloseMessage && (!fin || length > maxControlPayload) {
+		err = &protocolError{CloseProtocolError, "制御フレームが不正です"}
+		return
+	}
+	if c.readLimit > 0 && length > uint64(c.readLimit) {
+		err = ErrReadLimit
+		return
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	maskBytes(mask, payload)
+	return
+}
+
+// writeFrame マスクせずにフレームを1つ送信します。
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+	if c.writeWait > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeWait))
+	}
+	_, err := c.conn.Write(encodeFrame(true, opcode, nil, payload))
+	return err
+}
+
+// encodeFrame フレームをエンコードします。`mask`が`nil`の場合はマスクしません。
+func encodeFrame(fin bool, opcode int, mask []byte, payload []byte) []byte {
+	buf := make([]byte, 0, 14+len(payload))
+	b0 := byte(opcode)
+	if fin {
+		b0 |= finBit
+	}
+	var b1 byte
+	if mask != nil {
+		b1 = maskBit
+	}
+	length := len(payload)
+	switch {
+	case length <= 125:
+		buf = append(buf, b0, b1|byte(length))
+	case length <= 0xffff:
+		buf = append(buf, b0, b1|126, byte(length>>8), byte(length))
+	default:
+		buf = append(buf, b0, b1|127)
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(length))
+		buf = append(buf, ext[:]...)
+	}
+	if mask == nil {
+		return append(buf, payload...)
+	}
+	var key [4]byte
+	copy(key[:], mask)
+	buf = append(buf, key[:]...)
+	start := len(buf)
+	buf = append(buf, payload...)
+	maskBytes(key, buf[start:])
+	return buf
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
+
+// keepAlive `interval`ごとにPingフレームを送信します。
+func (c *Conn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.Ping(nil); err != nil {
+				return
+			}
+		}
+	}
+}
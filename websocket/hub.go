@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Hub ルームごとに接続を管理し、ルームの全接続へメッセージを送信します。
+//     hub := websocket.NewHub()
+//     router.WS("/rooms/:room", func(c interfaces.Context, conn *websocket.Conn) {
+//         room := c.Params().ByName("room")
+//         hub.Join(room, conn)
+//         defer hub.LeaveAll(conn)
+//         for {
+//             _, msg, err := conn.ReadMessage()
+//             if err != nil {
+//                 return
+//             }
+//             hub.Broadcast(room, websocket.TextMessage, msg)
+//         }
+//     })
+type Hub struct {
+	mu    sync.RWMutex
+	rooms map[string]map[*Conn]struct{}
+}
+
+// NewHub Hub Constructor
+func NewHub() *Hub {
+	return &Hub{rooms: make(map[string]map[*Conn]struct{})}
+}
+
+// Join `conn`を`room`に参加させます。
+func (h *Hub) Join(room string, conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conns, ok := h.rooms[room]
+	if !ok {
+		conns = make(map[*Conn]struct{})
+		h.rooms[room] = conns
+	}
+	conns[conn] = struct{}{}
+}
+
+// Leave `conn`を`room`から退出させます。
+func (h *Hub) Leave(room string, conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(room, conn)
+}
+
+// LeaveAll `conn`を全てのルームから退出させます。
+func (h *Hub) LeaveAll(conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for room := range h.rooms {
+		h.leave(room, conn)
+	}
+}
+
+func (h *Hub) leave(room string, conn *Conn) {
+	conns, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Len `room`に参加している接続の数
+func (h *Hub) Len(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Rooms 1つ以上の接続が参加しているルーム
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Broadcast `room`の全接続へメッセージを送信し、送信できた接続の数を返します。
+// 送信に失敗した接続は全てのルームから退出させて閉じます。
+func (h *Hub) Broadcast(room string, messageType int, data []byte) int {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.rooms[room]))
+	for conn := range h.rooms[room] {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sent := 0
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn *Conn) {
+			defer wg.Done()
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				h.LeaveAll(conn)
+				conn.Close(CloseGoingAway, "")
+				return
+			}
+			mu.Lock()
+			sent++
+			mu.Unlock()
+		}(conn)
+	}
+	wg.Wait()
+	return sent
+}
+
+// BroadcastJSON `v`をJSONにマーシャルし、`room`の全接続へテキストメッセージで送信します。
+func (h *Hub) BroadcastJSON(room string, v interface{}) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return h.Broadcast(room, TextMessage, data), nil
+}
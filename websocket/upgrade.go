@@ -0,0 +1,205 @@
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultReadLimit 受信できるメッセージの最大サイズ
+	DefaultReadLimit = 1 << 20 // 1MB
+	// DefaultWriteTimeout 1フレームの書き込みの期限
+	DefaultWriteTimeout = 10 * time.Second
+
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// ErrBadHandshake WebSocketのハンドシェイクが不正
+var ErrBadHandshake = errors.New("websocket: ハンドシェイクが不正です")
+
+// Options WebSocketの接続の設定
+//     router.SetWebSocketOptions(websocket.Options{
+//         Subprotocols: []string{"chat.v1"},
+//         CheckOrigin: func(r *http.Request) bool {
+//             return r.Header.Get("Origin") == "https://app.example.com"
+//         },
+//         PingInterval: 30 * time.Second,
+//         PongTimeout:  60 * time.Second,
+//     })
+type Options struct {
+	// ReadLimit 受信できるメッセージの最大サイズ(デフォルト1MB、負の値で無制限)
+	ReadLimit int64
+	// WriteTimeout 1フレームの書き込みの期限(デフォルト10秒、負の値で無制限)
+	WriteTimeout time.Duration
+	// Subprotocols サーバが対応するサブプロトコル(優先順)
+	Subprotocols []string
+	// CheckOrigin `Origin`ヘッダーを検証します。省略した場合は`Host`と同じオリジンのみ許可します。
+	CheckOrigin func(r *http.Request) bool
+	// PingInterval Pingフレームを送信する間隔(0の場合は送信しない)
+	PingInterval time.Duration
+	// PongTimeout この期間フレームを受信しない場合は接続を閉じます。(0の場合は無制限)
+	PongTimeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.ReadLimit == 0 {
+		o.ReadLimit = DefaultReadLimit
+	}
+	if o.WriteTimeout == 0 {
+		o.WriteTimeout = DefaultWriteTimeout
+	}
+	if o.CheckOrigin == nil {
+		o.CheckOrigin = sameOrigin
+	}
+	return o
+}
+
+// Upgrade HTTPの接続をWebSocketへアップグレードします。(RFC 6455 4.2)
+// ハンドシェイクが不正な場合はエラーレスポンスを書き込み、`ErrBadHandshake`を返します。
+//     conn, err := websocket.Upgrade(w, r, websocket.Options{})
+//     if err != nil {
+//         return
+//     }
+//     defer conn.Close(websocket.CloseNormalClosure, "")
+func Upgrade(w http.ResponseWriter, r *http.Request, opts Options) (*Conn, error) {
+	opts = opts.withDefaults()
+	if r.Method != http.MethodGet {
+		return nil, reject(w, http.StatusMethodNotAllowed)
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, reject(w, http.StatusBadRequest)
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, reject(w, http.StatusUpgradeRequired)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, reject(w, http.StatusBadRequest)
+	}
+	if !opts.CheckOrigin(r) {
+		return nil, reject(w, http.StatusForbidden)
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, reject(w, http.StatusInternalServerError)
+	}
+	subprotocol := selectSubprotocol(r, opts.Subprotocols)
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	var res strings.Builder
+	res.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	res.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		res.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	// ミドルウェアで設定したヘッダー(Set-Cookieなど)もハンドシェイクのレスポンスで送信する
+	responseHeader(w.Header()).Write(&res)
+	res.WriteString("\r\n")
+	if opts.WriteTimeout > 0 {
+		netConn.SetWriteDeadline(time.Now().Add(opts.WriteTimeout))
+	}
+	if _, err := netConn.Write([]byte(res.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetDeadline(time.Time{})
+	// ハイジャック前に読み込まれたデータを失わないように`brw.Reader`から読み込む
+	return newConn(netConn, brw.Reader, subprotocol, opts), nil
+}
+
+// IsWebSocketUpgrade WebSocketへのアップグレードのリクエストかどうか
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// excludedHeaders ハンドシェイクのレスポンスへ引き継がないヘッダー(ホップバイホップとハンドシェイクのヘッダー)
+var excludedHeaders = map[string]bool{
+	"Connection":               true,
+	"Keep-Alive":               true,
+	"Proxy-Connection":         true,
+	"Te":                       true,
+	"Trailer":                  true,
+	"Transfer-Encoding":        true,
+	"Upgrade":                  true,
+	"Content-Length":           true,
+	"Sec-Websocket-Accept":     true,
+	"Sec-Websocket-Protocol":   true,
+	"Sec-Websocket-Extensions": true,
+	"Sec-Websocket-Version":    true,
+}
+
+// responseHeader `header`からハンドシェイクのレスポンスへ引き継ぐヘッダーを返します。
+// `Connection`ヘッダーで指定されたヘッダーもホップバイホップとして除外します。
+func responseHeader(header http.Header) http.Header {
+	hopByHop := map[string]bool{}
+	for _, value := range header["Connection"] {
+		for _, v := range strings.Split(value, ",") {
+			hopByHop[http.CanonicalHeaderKey(strings.TrimSpace(v))] = true
+		}
+	}
+	res := http.Header{}
+	for key, values := range header {
+		key = http.CanonicalHeaderKey(key)
+		if excludedHeaders[key] || hopByHop[key] {
+			continue
+		}
+		res[key] = append(res[key], values...)
+	}
+	return res
+}
+
+func reject(w http.ResponseWriter, status int) error {
+	http.Error(w, http.StatusText(status), status)
+	return ErrBadHandshake
+}
+
+// acceptKey `Sec-WebSocket-Accept`ヘッダーの値
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains カンマ区切りのヘッダーに`token`が含まれるかどうか
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectSubprotocol サーバが対応するサブプロトコルのうち、クライアントが要求した最初のもの
+func selectSubprotocol(r *http.Request, supported []string) string {
+	for _, s := range supported {
+		if headerContains(r.Header, "Sec-WebSocket-Protocol", s) {
+			return s
+		}
+	}
+	return ""
+}
+
+// sameOrigin `Origin`ヘッダーが無いか、`Host`と同じホストの場合に許可します。
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
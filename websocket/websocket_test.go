@@ -0,0 +1,294 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// client テスト用のWebSocketクライアント(フレームをマスクして送信する)
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func handshake(t *testing.T, server *httptest.Server, method string, header map[string]string) (*client, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	assert.Nil(t, err)
+	req, _ := http.NewRequest(method, server.URL, nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", testKey)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	for key, val := range header {
+		if val == "" {
+			req.Header.Del(key)
+		} else {
+			req.Header.Set(key, val)
+		}
+	}
+	assert.Nil(t, req.Write(conn))
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	assert.Nil(t, err)
+	return &client{t: t, conn: conn, br: br}, res
+}
+
+func dial(t *testing.T, server *httptest.Server) *client {
+	c, res := handshake(t, server, http.MethodGet, nil)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	return c
+}
+
+func (c *client) send(fin bool, opcode int, payload []byte) {
+	_, err := c.conn.Write(encodeFrame(fin, opcode, []byte{1, 2, 3, 4}, payload))
+	assert.Nil(c.t, err)
+}
+
+func (c *client) read() (opcode int, payload []byte) {
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	var header [2]byte
+	_, err := io.ReadFull(c.br, header[:])
+	assert.Nil(c.t, err)
+	assert.Equal(c.t, byte(0), header[1]&maskBit, "サーバのフレームはマスクしない")
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload = make([]byte, length)
+	_, err = io.ReadFull(c.br, payload)
+	assert.Nil(c.t, err)
+	return int(header[0] & 0x0f), payload
+}
+
+func (c *client) readClose() int {
+	opcode, payload := c.read()
+	assert.Equal(c.t, CloseMessage, opcode)
+	if len(payload) < 2 {
+		return CloseNoStatusReceived
+	}
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+func closePayload(code int, reason string) []byte {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	return append(payload, reason...)
+}
+
+// echoServer 受信したメッセージを返し、終了時のエラーを`errc`へ送信するサーバ
+func echoServer(opts Options) (*httptest.Server, chan error) {
+	errc := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, opts)
+		if err != nil {
+			return
+		}
+		defer conn.Close(CloseNormalClosure, "")
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				errc <- err
+				return
+			}
+			conn.WriteMessage(mt, data)
+		}
+	}))
+	return server, errc
+}
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455 1.3の例
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey(testKey))
+}
+
+func TestHandshake(t *testing.T) {
+	server, _ := echoServer(Options{Subprotocols: []string{"chat.v2", "chat.v1"}})
+	defer server.Close()
+
+	_, res := handshake(t, server, http.MethodGet, map[string]string{"Sec-WebSocket-Protocol": "chat.v1, chat.v2"})
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "chat.v2", res.Header.Get("Sec-WebSocket-Protocol"))
+
+	tests := []struct {
+		method string
+		header map[string]string
+		status int
+	}{
+		{http.MethodPost, nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, map[string]string{"Upgrade": ""}, http.StatusBadRequest},
+		{http.MethodGet, map[string]string{"Sec-WebSocket-Version": "8"}, http.StatusUpgradeRequired},
+		{http.MethodGet, map[string]string{"Sec-WebSocket-Key": "short"}, http.StatusBadRequest},
+		{http.MethodGet, map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		_, res := handshake(t, server, tt.method, tt.header)
+		assert.Equal(t, tt.status, res.StatusCode, tt.header)
+	}
+
+	_, res = handshake(t, server, http.MethodGet, map[string]string{"Origin": server.URL})
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+}
+
+func TestHandshakeHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=1")
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Header().Set("Connection", "X-Hop")
+		w.Header().Set("X-Hop", "1")
+		w.Header().Set("Content-Length", "10")
+		w.Header().Set("Sec-WebSocket-Accept", "invalid")
+		conn, err := Upgrade(w, r, Options{})
+		if err != nil {
+			return
+		}
+		conn.Close(CloseNormalClosure, "")
+	}))
+	defer server.Close()
+
+	_, res := handshake(t, server, http.MethodGet, nil)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "session=1", res.Header.Get("Set-Cookie"))
+	assert.Equal(t, "req-1", res.Header.Get("X-Request-Id"))
+	assert.Equal(t, "Upgrade", res.Header.Get("Connection"))
+	assert.Empty(t, res.Header.Get("X-Hop"))
+	assert.Empty(t, res.Header.Get("Content-Length"))
+	assert.Equal(t, []string{"s3pPLMBiTxaQ9kYGzzhZRbK+xOo="}, res.Header["Sec-Websocket-Accept"])
+}
+
+func TestEcho(t *testing.T) {
+	server, errc := echoServer(Options{})
+	defer server.Close()
+	c := dial(t, server)
+
+	c.send(true, TextMessage, []byte("こんにちは"))
+	opcode, payload := c.read()
+	assert.Equal(t, TextMessage, opcode)
+	assert.Equal(t, "こんにちは", string(payload))
+
+	// 分割したメッセージの途中に制御フレームを挟むことができる
+	c.send(false, BinaryMessage, []byte{1, 2})
+	c.send(true, PingMessage, []byte("ping"))
+	opcode, payload = c.read()
+	assert.Equal(t, PongMessage, opcode)
+	assert.Equal(t, "ping", string(payload))
+	big := make([]byte, 300)
+	c.send(true, continuationFrame, big)
+	opcode, payload = c.read()
+	assert.Equal(t, BinaryMessage, opcode)
+	assert.Equal(t, append([]byte{1, 2}, big...), payload)
+
+	c.send(true, CloseMessage, closePayload(CloseGoingAway, "bye"))
+	assert.Equal(t, CloseGoingAway, c.readClose())
+	err := <-errc
+	assert.True(t, IsCloseError(err, CloseGoingAway))
+	assert.Equal(t, "bye", err.(*CloseError).Text)
+}
+
+func TestProtocolError(t *testing.T) {
+	tests := []struct {
+		name  string
+		send  func(c *client)
+		code  int
+		limit int64
+	}{
+		{"unmasked", func(c *client) { c.conn.Write(encodeFrame(true, TextMessage, nil, []byte("a"))) }, CloseProtocolError, 0},
+		{"invalid utf8", func(c *client) { c.send(true, TextMessage, []byte{0xff, 0xfe}) }, CloseInvalidFramePayloadData, 0},
+		{"continuation", func(c *client) { c.send(true, continuationFrame, []byte("a")) }, CloseProtocolError, 0},
+		{"interleaved", func(c *client) {
+			c.send(false, TextMessage, []byte("a"))
+			c.send(true, TextMessage, []byte("b"))
+		}, CloseProtocolError, 0},
+		{"opcode", func(c *client) { c.send(true, 3, nil) }, CloseProtocolError, 0},
+		{"close payload", func(c *client) { c.send(true, CloseMessage, []byte{3}) }, CloseProtocolError, 0},
+		{"close code", func(c *client) { c.send(true, CloseMessage, closePayload(1005, "")) }, CloseProtocolError, 0},
+		{"fragmented ping", func(c *client) { c.send(false, PingMessage, nil) }, CloseProtocolError, 0},
+		{"read limit", func(c *client) { c.send(true, BinaryMessage, make([]byte, 11)) }, CloseMessageTooBig, 10},
+		{"read limit fragmented", func(c *client) {
+			c.send(false, BinaryMessage, make([]byte, 6))
+			c.send(true, continuationFrame, make([]byte, 6))
+		}, CloseMessageTooBig, 10},
+	}
+	for _, tt := range tests {
+		server, errc := echoServer(Options{ReadLimit: tt.limit})
+		c := dial(t, server)
+		tt.send(c)
+		assert.Equal(t, tt.code, c.readClose(), tt.name)
+		assert.NotNil(t, <-errc, tt.name)
+		server.Close()
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	server, errc := echoServer(Options{PingInterval: 10 * time.Millisecond, PongTimeout: 50 * time.Millisecond})
+	defer server.Close()
+	c := dial(t, server)
+	opcode, _ := c.read()
+	assert.Equal(t, PingMessage, opcode)
+	c.send(true, PongMessage, nil)
+
+	// Pongを返さない場合は接続を閉じる
+	select {
+	case err := <-errc:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("接続が閉じられませんでした")
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	joined := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, Options{})
+		if err != nil {
+			return
+		}
+		defer conn.Close(CloseNormalClosure, "")
+		hub.Join("room1", conn)
+		defer hub.LeaveAll(conn)
+		joined <- struct{}{}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	c1 := dial(t, server)
+	c2 := dial(t, server)
+	<-joined
+	<-joined
+	assert.Equal(t, 2, hub.Len("room1"))
+	assert.Equal(t, []string{"room1"}, hub.Rooms())
+
+	sent, err := hub.BroadcastJSON("room1", map[string]string{"msg": "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, sent)
+	for _, c := range []*client{c1, c2} {
+		opcode, payload := c.read()
+		assert.Equal(t, TextMessage, opcode)
+		assert.Equal(t, `{"msg":"hello"}`, string(payload))
+	}
+	assert.Equal(t, 0, hub.Broadcast("room2", TextMessage, []byte("x")))
+
+	c1.send(true, CloseMessage, closePayload(CloseNormalClosure, ""))
+	assert.Equal(t, CloseNormalClosure, c1.readClose())
+	assert.Eventually(t, func() bool {
+		return hub.Len("room1") == 1
+	}, time.Second, 5*time.Millisecond)
+}